@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/muzz/api/rest"
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/rest/transformer"
+	"github.com/muzz/api/service"
+	"github.com/sirupsen/logrus"
+)
+
+// createAdmin parses the create-admin command arguments and returns an
+// invocable function seeding the admin account through the user service.
+func createAdmin(args []string) (func(l *logrus.Logger, userConn service.UserConnector) error, error) {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+
+	var def definition.UserInput
+	fs.StringVar(&def.Email, "email", "", "admin email")
+	fs.StringVar(&def.Password, "password", "", "admin password")
+	fs.StringVar(&def.Name, "name", "admin", "admin name")
+	fs.StringVar(&def.Gender, "gender", "", "admin gender, M or F")
+	fs.StringVar(&def.DOB, "dob", "1970-01-01", "admin date of birth (YYYY-MM-DD)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if def.Email == "" || def.Password == "" || def.Gender == "" {
+		return nil, errors.New("create-admin: -email, -password and -gender are required")
+	}
+
+	// the admin is held to the same rules as users created through the api
+	if err := rest.NewValidator().Struct(def); err != nil {
+		return nil, fmt.Errorf("create-admin: %w", err)
+	}
+	in := transformer.FromUserInputDefToEntity(def)
+
+	return func(l *logrus.Logger, userConn service.UserConnector) error {
+		user, err := userConn.SeedAdmin(context.Background(), in)
+		if err != nil {
+			return err
+		}
+
+		l.WithField("user_id", user.ID).Info("admin user ready")
+		return nil
+	}, nil
+}
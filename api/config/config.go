@@ -1,18 +1,47 @@
 package config
 
 import (
+	"time"
+
+	"github.com/muzz/api/migrations"
+	"github.com/muzz/api/pkg/health"
+	"github.com/muzz/api/pkg/i18n"
 	"github.com/muzz/api/pkg/logger"
+	"github.com/muzz/api/pkg/mail"
+	"github.com/muzz/api/pkg/password"
 	"github.com/muzz/api/pkg/pg"
 	"github.com/muzz/api/pkg/redis"
+	"github.com/muzz/api/pkg/sms"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/service"
 )
 
 type Config struct {
-	Port             string `env:"SRV_PORT" envDefault:"3000"`
-	MigrationPath    string `env:"MIGRATION_PATH"`
-	SecretKey        string `env:"SECRET_KEY"`
-	PostgresSettings pg.PostgresSettings
-	RedisSettings    redis.RedisSettings
-	LoggerSettings   logger.Settings
+	Port               string        `env:"SRV_PORT" envDefault:"3000"`
+	MetricsPort        string        `env:"METRICS_PORT" envDefault:"9090"`
+	DrainDelay         time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"5s"`
+	DrainTimeout       time.Duration `env:"SHUTDOWN_DRAIN_TIMEOUT" envDefault:"15s"`
+	MigrationPath      string        `env:"MIGRATION_PATH"`
+	AutoMigrate        bool          `env:"AUTO_MIGRATE" envDefault:"true"`
+	SecretKey          string        `env:"SECRET_KEY"`
+	TrustProxy         bool          `env:"TRUST_PROXY_HEADERS" envDefault:"false"`
+	RepositorySettings repository.Settings
+	PostgresSettings   pg.PostgresSettings
+	RedisSettings      redis.RedisSettings
+	LoggerSettings     logger.Settings
+	MailSettings       mail.Settings
+	SMSSettings        sms.Settings
+	I18NSettings       i18n.Settings
+	TracingSettings    tracing.Settings
+	HealthSettings     health.Settings
+	PasswordSettings   password.Settings
+	UserSettings       service.UserSettings
+	WebAuthnSettings   service.WebAuthnSettings
+}
+
+func NewRepositorySettings(config Config) repository.Settings {
+	return config.RepositorySettings
 }
 
 func NewPostgresSettings(config Config) pg.PostgresSettings {
@@ -27,9 +56,42 @@ func NewLoggerSettings(config Config) logger.Settings {
 	return config.LoggerSettings
 }
 
+func NewMailSettings(config Config) mail.Settings {
+	return config.MailSettings
+}
+
+func NewSMSSettings(config Config) sms.Settings {
+	return config.SMSSettings
+}
+
+func NewI18NSettings(config Config) i18n.Settings {
+	return config.I18NSettings
+}
+
+func NewTracingSettings(config Config) tracing.Settings {
+	return config.TracingSettings
+}
+
+func NewHealthSettings(config Config) health.Settings {
+	return config.HealthSettings
+}
+
+func NewPasswordSettings(config Config) password.Settings {
+	return config.PasswordSettings
+}
+
+func NewUserSettings(config Config) service.UserSettings {
+	return config.UserSettings
+}
+
+func NewWebAuthnSettings(config Config) service.WebAuthnSettings {
+	return config.WebAuthnSettings
+}
+
 func NewMigrationSettings(config Config) pg.PgMigrationSettings {
 	return pg.PgMigrationSettings{
 		MigrationPath: config.MigrationPath,
+		Embedded:      migrations.FS,
 		DataSource:    config.PostgresSettings.DataSource,
 	}
 }
@@ -3,16 +3,23 @@ package di
 import (
 	"net/http"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/muzz/api/config"
 	"github.com/muzz/api/pkg/env"
+	"github.com/muzz/api/pkg/health"
+	"github.com/muzz/api/pkg/i18n"
 	"github.com/muzz/api/pkg/logger"
-	"github.com/muzz/api/pkg/pg"
-	"github.com/muzz/api/pkg/redis"
+	"github.com/muzz/api/pkg/mail"
+	"github.com/muzz/api/pkg/metrics"
+	"github.com/muzz/api/pkg/password"
+	"github.com/muzz/api/pkg/sms"
+	"github.com/muzz/api/pkg/tracing"
 	"github.com/muzz/api/repository"
 	"github.com/muzz/api/rest"
 	"github.com/muzz/api/rest/middleware"
 	"github.com/muzz/api/service"
 	"github.com/sirupsen/logrus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/dig"
 )
 
@@ -23,6 +30,10 @@ func buildConfig(c *dig.Container) error {
 		return err
 	}
 
+	if err := c.Provide(config.NewRepositorySettings); err != nil {
+		return err
+	}
+
 	if err := c.Provide(config.NewPostgresSettings); err != nil {
 		return err
 	}
@@ -39,52 +50,138 @@ func buildConfig(c *dig.Container) error {
 		return err
 	}
 
+	if err := c.Provide(config.NewMailSettings); err != nil {
+		return err
+	}
+
+	if err := c.Provide(config.NewSMSSettings); err != nil {
+		return err
+	}
+
+	if err := c.Provide(config.NewI18NSettings); err != nil {
+		return err
+	}
+
+	if err := c.Provide(config.NewTracingSettings); err != nil {
+		return err
+	}
+
+	if err := c.Provide(config.NewHealthSettings); err != nil {
+		return err
+	}
+
+	if err := c.Provide(config.NewPasswordSettings); err != nil {
+		return err
+	}
+
+	if err := c.Provide(config.NewUserSettings); err != nil {
+		return err
+	}
+
+	if err := c.Provide(config.NewWebAuthnSettings); err != nil {
+		return err
+	}
+
 	if err := c.Provide(logger.New); err != nil {
 		return err
 	}
 
-	if err := c.Provide(func(l *logrus.Logger, c pg.PostgresSettings) (*pg.Postgres, error) {
-		p, err := pg.NewPostgres(c)
+	if err := c.Provide(func(l *logrus.Logger, c mail.Settings) (mail.Sender, error) {
+		m, err := mail.New(c)
 		if err != nil {
-			l.Error("failed to open database connection")
+			l.Error("failed to set up mail sender")
 		}
-		return p, err
+		return m, err
 	}); err != nil {
 		return err
 	}
 
-	if err := c.Provide(pg.NewPgMigration); err != nil {
+	if err := c.Provide(func(l *logrus.Logger, c sms.Settings) (sms.Sender, error) {
+		s, err := sms.New(c)
+		if err != nil {
+			l.Error("failed to set up sms sender")
+		}
+		return s, err
+	}); err != nil {
 		return err
 	}
 
-	if err := c.Provide(func(l *logrus.Logger, c redis.RedisSettings) (*redis.Redis, error) {
-		r, err := redis.NewRedis(c)
+	if err := c.Provide(func(l *logrus.Logger, c i18n.Settings) (*i18n.Translator, error) {
+		t, err := i18n.New(c)
 		if err != nil {
-			l.Error("failed to open cache connection")
+			l.Error("failed to set up translations")
 		}
-		return r, err
+		return t, err
 	}); err != nil {
 		return err
 	}
 
-	if err := c.Provide(http.NewServeMux); err != nil {
+	if err := c.Provide(func(l *logrus.Logger, lc *Lifecycle, c tracing.Settings) (*sdktrace.TracerProvider, error) {
+		t, err := tracing.New(c)
+		if err != nil {
+			l.Error("failed to set up tracing")
+			return nil, err
+		}
+		// flushes the spans not exported yet
+		lc.OnClose("tracer provider", t.Shutdown)
+		return t, nil
+	}); err != nil {
 		return err
 	}
 
-	if err := c.Provide(func(l *logrus.Logger, r *redis.Redis, config config.Config) repository.AuthConnector {
-		return repository.NewAuthRepo(l, r, config.SecretKey)
+	if err := c.Provide(func(l *logrus.Logger, c password.Settings) (password.Hasher, error) {
+		h, err := password.New(c)
+		if err != nil {
+			l.Error("failed to set up password hasher")
+		}
+		return h, err
 	}); err != nil {
 		return err
 	}
 
-	if err := c.Provide(func(l *logrus.Logger, p *pg.Postgres) repository.UserConnector {
-		return repository.NewUserRepo(l, p)
+	if err := c.Provide(func(l *logrus.Logger, c password.Settings) (password.Policy, error) {
+		p, err := password.NewPolicy(c)
+		if err != nil {
+			l.Error("failed to load password policy")
+		}
+		return p, err
 	}); err != nil {
 		return err
 	}
 
-	if err := c.Provide(func(r repository.UserConnector, a repository.AuthConnector) service.UserConnector {
-		return service.NewUserService(r, a)
+	if err := c.Provide(health.NewReadiness); err != nil {
+		return err
+	}
+
+	if err := c.Provide(http.NewServeMux); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, c service.WebAuthnSettings) (*webauthn.WebAuthn, error) {
+		w, err := service.NewWebAuthn(c)
+		if err != nil {
+			l.Error("failed to set up webauthn")
+		}
+		return w, err
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(
+		l *logrus.Logger,
+		r repository.UserConnector,
+		a repository.AuthConnector,
+		t repository.TwoFactorConnector,
+		p repository.PasskeyConnector,
+		au repository.AuditConnector,
+		w *webauthn.WebAuthn,
+		m mail.Sender,
+		sm sms.Sender,
+		pp password.Policy,
+		mt *metrics.Metrics,
+		s service.UserSettings,
+	) service.UserConnector {
+		return service.NewUserService(l, r, a, t, p, au, w, m, sm, pp, mt, s)
 	}); err != nil {
 		return err
 	}
@@ -95,8 +192,50 @@ func buildConfig(c *dig.Container) error {
 		return err
 	}
 
-	if err := c.Provide(func(s service.AuthConnector) middleware.AuthMiddleware {
-		return middleware.NewAuthHandler(s)
+	if err := c.Provide(func(l *logrus.Logger, s service.AuthConnector) middleware.AuthMiddleware {
+		return middleware.NewAuthHandler(l, s)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(config config.Config) middleware.ClientMiddleware {
+		return middleware.NewClientHandler(config.TrustProxy)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(t *sdktrace.TracerProvider, router *http.ServeMux) middleware.TracingMiddleware {
+		return middleware.NewTracingHandler(t, router)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, router *http.ServeMux) middleware.LoggerMiddleware {
+		return middleware.NewLoggerHandler(l, router)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(m *metrics.Metrics, router *http.ServeMux) middleware.MetricsMiddleware {
+		return middleware.NewMetricsHandler(m, router)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(t *i18n.Translator) middleware.LocaleMiddleware {
+		return middleware.NewLocaleHandler(t)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func() middleware.RequestIDMiddleware {
+		return middleware.NewRequestIDHandler()
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func() middleware.RoleMiddleware {
+		return middleware.NewRoleHandler()
 	}); err != nil {
 		return err
 	}
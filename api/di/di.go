@@ -7,9 +7,18 @@ import (
 func NewDI() (*dig.Container, error) {
 	c := dig.New()
 
+	lc := NewLifecycle()
+	if err := c.Provide(func() *Lifecycle { return lc }); err != nil {
+		return nil, err
+	}
+
 	if err := buildConfig(c); err != nil {
 		return nil, err
 	}
 
+	if err := buildRepositories(c); err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
@@ -0,0 +1,58 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type hook struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+// Lifecycle closes the components built by the container. Providers append
+// a hook once their component is built, since dig builds dependencies first
+// running the hooks in reverse order closes every component before the ones
+// it depends on.
+type Lifecycle struct {
+	mu    sync.Mutex
+	hooks []hook
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// OnClose registers the function releasing the component called name.
+func (l *Lifecycle) OnClose(name string, close func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.hooks = append(l.hooks, hook{name: name, close: close})
+}
+
+// Close runs the hooks in reverse order, every hook runs even when a previous
+// one fails. It can only be called once.
+func (l *Lifecycle) Close(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks
+	l.hooks = nil
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", hooks[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closer adapts the Close method of components that take no context.
+func closer(close func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return close()
+	}
+}
@@ -0,0 +1,196 @@
+package di
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muzz/api/config"
+	"github.com/muzz/api/pkg/health"
+	"github.com/muzz/api/pkg/metrics"
+	"github.com/muzz/api/pkg/password"
+	"github.com/muzz/api/pkg/pg"
+	"github.com/muzz/api/pkg/redis"
+	"github.com/muzz/api/repository"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/dig"
+)
+
+// buildRepositories provides the repositories of the configured driver. The
+// postgres and redis connections are only provided along with the postgres
+// repositories, so that the memory driver runs without them.
+func buildRepositories(c *dig.Container) error {
+	var settings repository.Settings
+	if err := c.Invoke(func(s repository.Settings) { settings = s }); err != nil {
+		return err
+	}
+
+	switch settings.Driver {
+	case repository.DriverPostgres:
+		return buildPostgresRepositories(c)
+	case repository.DriverMemory:
+		return buildMemoryRepositories(c)
+	default:
+		return fmt.Errorf("unknown repository driver: %s", settings.Driver)
+	}
+}
+
+func buildPostgresRepositories(c *dig.Container) error {
+	if err := c.Provide(func(l *logrus.Logger, lc *Lifecycle, c pg.PostgresSettings) (*pg.Postgres, error) {
+		p, err := pg.NewPostgres(c)
+		if err != nil {
+			l.Error("failed to open database connection")
+			return nil, err
+		}
+		lc.OnClose("database connection", closer(p.Close))
+		return p, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, lc *Lifecycle, c pg.PgMigrationSettings) (pg.PgMigration, error) {
+		m, err := pg.NewPgMigration(l, c)
+		if err != nil {
+			l.Error("failed to open migration connection")
+			return pg.PgMigration{}, err
+		}
+		lc.OnClose("migration connection", closer(m.Close))
+		return m, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, lc *Lifecycle, c redis.RedisSettings) (*redis.Redis, error) {
+		r, err := redis.NewRedis(c)
+		if err != nil {
+			l.Error("failed to open cache connection")
+			return nil, err
+		}
+		lc.OnClose("cache connection", closer(r.Close))
+		return r, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, p *pg.Postgres, r *redis.Redis) (*metrics.Metrics, error) {
+		m, err := metrics.New()
+		if err == nil {
+			err = m.RegisterDB("postgres", p.Raw())
+		}
+		if err == nil {
+			err = m.RegisterRedis(r.Client)
+		}
+		if err != nil {
+			l.Error("failed to set up metrics")
+		}
+		return m, err
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(
+		l *logrus.Logger,
+		c health.Settings,
+		m pg.PgMigration,
+		p *pg.Postgres,
+		r *redis.Redis,
+	) (health.Checker, error) {
+		latest, err := m.Latest()
+		if err != nil {
+			l.Error("failed to read migrations")
+			return health.Checker{}, err
+		}
+
+		return health.NewChecker(c,
+			health.Check{Name: "postgres", Run: p.Ping},
+			health.Check{Name: "redis", Run: r.PingContext},
+			health.Check{Name: "migrations", Run: func(ctx context.Context) error {
+				return p.CheckMigrations(ctx, latest)
+			}},
+		), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, r *redis.Redis, h password.Hasher, config config.Config) repository.AuthConnector {
+		return repository.NewAuthRepo(l, r, h, config.SecretKey)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, p *pg.Postgres) repository.UserConnector {
+		return repository.NewUserRepo(l, p)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, p *pg.Postgres, config config.Config) repository.TwoFactorConnector {
+		return repository.NewTwoFactorRepo(l, p, config.SecretKey)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, p *pg.Postgres) repository.PasskeyConnector {
+		return repository.NewPasskeyRepo(l, p)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, p *pg.Postgres) repository.AuditConnector {
+		return repository.NewAuditRepo(l, p)
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func buildMemoryRepositories(c *dig.Container) error {
+	if err := c.Provide(func(l *logrus.Logger) (*metrics.Metrics, error) {
+		m, err := metrics.New()
+		if err != nil {
+			l.Error("failed to set up metrics")
+		}
+		return m, err
+	}); err != nil {
+		return err
+	}
+
+	// there are no dependencies to check
+	if err := c.Provide(func(c health.Settings) health.Checker {
+		return health.NewChecker(c)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger, h password.Hasher, config config.Config) repository.AuthConnector {
+		return repository.NewMemoryAuthRepo(l, h, config.SecretKey)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger) repository.UserConnector {
+		return repository.NewMemoryUserRepo(l)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger) repository.TwoFactorConnector {
+		return repository.NewMemoryTwoFactorRepo(l)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger) repository.PasskeyConnector {
+		return repository.NewMemoryPasskeyRepo(l)
+	}); err != nil {
+		return err
+	}
+
+	if err := c.Provide(func(l *logrus.Logger) repository.AuditConnector {
+		return repository.NewMemoryAuditRepo(l)
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,55 +2,201 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/muzz/api/config"
 	"github.com/muzz/api/di"
+	"github.com/muzz/api/pkg/health"
+	"github.com/muzz/api/pkg/metrics"
 	"github.com/muzz/api/pkg/pg"
+	"github.com/muzz/api/pkg/requestid"
+	"github.com/muzz/api/repository"
 	"github.com/muzz/api/rest"
+	"github.com/muzz/api/rest/middleware"
 
 	"github.com/rs/cors"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/dig"
 	"golang.org/x/sync/errgroup"
 )
 
+// closeTimeout bounds the time components get to release their resources
+const closeTimeout = 10 * time.Second
+
 func main() {
 	c, err := di.NewDI()
 	if err != nil {
-		panic(err)
+		exit(err)
 	}
 
-	if err := c.Invoke(func(migration pg.PgMigration) error { return migration.Up() }); err != nil {
-		panic(err)
+	err = run(c, os.Args[1:])
+	closeAll(c)
+	if err != nil {
+		exit(err)
 	}
+}
 
-	if err := c.Invoke(rest.NewRest); err != nil {
-		panic(err)
+// exit reports err and exits with a failure status, asking for the usage of a
+// command with -h not being a failure.
+func exit(err error) {
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// run dispatches the command line to the matching command, serving the api
+// when none is given.
+func run(c *dig.Container, args []string) error {
+	cmd := "serve"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "serve":
+		return serve(c)
+	case "migrate":
+		fn, err := migrate(args)
+		if err != nil {
+			return err
+		}
+		return c.Invoke(fn)
+	case "create-admin":
+		fn, err := createAdmin(args)
+		if err != nil {
+			return err
+		}
+		return c.Invoke(fn)
+	case "seed":
+		fn, err := seedUsers(args)
+		if err != nil {
+			return err
+		}
+		return c.Invoke(fn)
+	case "version":
+		printVersion()
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q, expected serve, migrate, create-admin, seed or version", cmd)
+	}
+}
+
+// serve migrates the database when AUTO_MIGRATE is set then serves the api.
+// It refuses to serve a database migrated by a newer version of the service.
+func serve(c *dig.Container) error {
+	if err := c.Invoke(func(config config.Config) error {
+		// the memory repositories have no database to migrate
+		if config.RepositorySettings.Driver == repository.DriverMemory {
+			return nil
+		}
+
+		return c.Invoke(func(migration pg.PgMigration) error {
+			ctx := context.Background()
+
+			if err := migration.CheckUnknown(ctx); err != nil {
+				return err
+			}
+
+			if !config.AutoMigrate {
+				return nil
+			}
+			return migration.Up(ctx)
+		})
+	}); err != nil {
+		return err
 	}
 
-	if err := c.Invoke(start); err != nil {
-		panic(err)
+	if err := c.Invoke(rest.NewRest); err != nil {
+		return err
 	}
+
+	return c.Invoke(start)
 }
 
-func start(c config.Config, router *http.ServeMux) error {
-	g, _ := errgroup.WithContext(context.Background())
+// closeAll releases the components built by the container, in reverse
+// dependency order.
+func closeAll(c *dig.Container) {
+	_ = c.Invoke(func(l *logrus.Logger, lc *di.Lifecycle) {
+		ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		defer cancel()
+
+		if err := lc.Close(ctx); err != nil {
+			l.WithError(err).Error("failed to close components")
+		}
+	})
+}
+
+func start(
+	c config.Config,
+	l *logrus.Logger,
+	router *http.ServeMux,
+	readiness *health.Readiness,
+	requestID middleware.RequestIDMiddleware,
+	tracing middleware.TracingMiddleware,
+	log middleware.LoggerMiddleware,
+	metric middleware.MetricsMiddleware,
+	m *metrics.Metrics,
+	locale middleware.LocaleMiddleware,
+	client middleware.ClientMiddleware,
+) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	g, ctx := errgroup.WithContext(ctx)
 
 	corss := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "HEAD", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{requestid.Header},
 		AllowCredentials: true,
 	})
 
 	srv := &http.Server{
-		Handler:      corss.Handler(router),
+		Handler:      corss.Handler(requestID.Handle(tracing.Handle(log.Handle(metric.Handle(locale.Handle(client.Handle(router))))))),
 		Addr:         ":" + c.Port,
 		WriteTimeout: 15 * time.Second,
 		ReadTimeout:  15 * time.Second,
 	}
 
-	g.Go(func() error { return srv.ListenAndServe() })
+	// metrics are served on an internal port, not to be exposed publicly
+	internal := http.NewServeMux()
+	internal.Handle("GET /metrics", m.Handler())
+
+	metricsSrv := &http.Server{
+		Handler:      internal,
+		Addr:         ":" + c.MetricsPort,
+		WriteTimeout: 15 * time.Second,
+		ReadTimeout:  15 * time.Second,
+	}
+
+	g.Go(func() error { return listen(srv) })
+	g.Go(func() error { return listen(metricsSrv) })
+
+	// on a signal, or if a server fails, the service is reported as not ready
+	// and in-flight requests get DrainTimeout to complete
+	g.Go(func() error {
+		<-ctx.Done()
+		l.Info("shutting down")
+
+		readiness.Drain()
+		time.Sleep(c.DrainDelay)
+
+		drainCtx, cancel := context.WithTimeout(context.Background(), c.DrainTimeout)
+		defer cancel()
+
+		return errors.Join(srv.Shutdown(drainCtx), metricsSrv.Shutdown(drainCtx))
+	})
 
 	if err := g.Wait(); err != nil {
 		return err
@@ -58,3 +204,11 @@ func start(c config.Config, router *http.ServeMux) error {
 
 	return nil
 }
+
+// listen runs srv until it is shut down.
+func listen(srv *http.Server) error {
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/muzz/api/pkg/pg"
+)
+
+const migrateUsage = "migrate: expected up, down, status, redo or create NAME"
+
+// migrate parses the migrate command arguments and returns an invocable
+// function running the migration subcommand. Commands changing the schema
+// hold an advisory lock, concurrent migrators wait for each other.
+func migrate(args []string) (func(migration pg.PgMigration) error, error) {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() == 0 {
+		return nil, errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+
+	switch cmd := fs.Arg(0); cmd {
+	case "up":
+		return func(migration pg.PgMigration) error { return migration.Up(ctx) }, nil
+	case "down":
+		return func(migration pg.PgMigration) error { return migration.Down(ctx) }, nil
+	case "redo":
+		return func(migration pg.PgMigration) error { return migration.Redo(ctx) }, nil
+	case "status":
+		return func(migration pg.PgMigration) error { return migration.Status(ctx) }, nil
+	case "create":
+		if fs.NArg() != 2 {
+			return nil, errors.New("migrate create: expected the name of the migration")
+		}
+		name := fs.Arg(1)
+		return func(migration pg.PgMigration) error { return migration.Create(name) }, nil
+	default:
+		return nil, fmt.Errorf("%s, got %q", migrateUsage, cmd)
+	}
+}
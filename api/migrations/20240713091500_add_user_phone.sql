@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+ALTER TABLE users ADD COLUMN phone VARCHAR(16) UNIQUE;
+ALTER TABLE users ADD COLUMN phone_verified BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD CONSTRAINT users_email_or_phone CHECK (email IS NOT NULL OR phone IS NOT NULL);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- refuse to roll back rather than delete the accounts without an email
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE email IS NULL) THEN
+        RAISE EXCEPTION 'users registered with a phone only have no email to roll back to';
+    END IF;
+END $$;
+ALTER TABLE users DROP CONSTRAINT users_email_or_phone;
+ALTER TABLE users DROP COLUMN phone_verified;
+ALTER TABLE users DROP COLUMN phone;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+// Package migrations embeds the sql migrations of the database, so that the
+// binary does not depend on the migrations directory at runtime.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+type contextKey struct{}
+
+// Info describes the client behind the current request.
+type Info struct {
+	IP         string
+	UserAgent  string
+	DeviceID   string
+	DeviceName string
+}
+
+// Fingerprint identifies the device that issued the request. The ip is left
+// out on purpose since it changes whenever a phone switches networks.
+func (i Info) Fingerprint() string {
+	sum := sha256.Sum256([]byte(i.DeviceID + "\x00" + i.UserAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the client info stored in ctx, or the zero value when
+// the context does not come from an http request (e.g. cli commands).
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
@@ -0,0 +1,106 @@
+package errs
+
+import (
+	"errors"
+	"time"
+)
+
+// Kind classifies errors by how they should be reported to clients.
+type Kind string
+
+const (
+	Internal     Kind = "internal"
+	NotFound     Kind = "not_found"
+	Conflict     Kind = "conflict"
+	Unauthorized Kind = "unauthorized"
+	Forbidden    Kind = "forbidden"
+	Validation   Kind = "validation"
+	RateLimited  Kind = "rate_limited"
+
+	// kinds raised by the transport layer rather than by services
+	TooLarge             Kind = "too_large"
+	UnsupportedMediaType Kind = "unsupported_media_type"
+)
+
+// Error is a domain error. Code is a stable machine-readable identifier that
+// clients may rely on, Message and Detail a human-readable description. The
+// wrapped cause is meant for logs only and never shown to clients.
+type Error struct {
+	Kind       Kind
+	Code       string
+	Message    string
+	Detail     string
+	RetryAfter time.Duration
+	err        error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.Describe() + ": " + e.err.Error()
+	}
+	return e.Describe()
+}
+
+// Describe returns the message meant for clients, without the cause.
+func (e *Error) Describe() string {
+	if e.Detail != "" {
+		return e.Message + ": " + e.Detail
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is matches errors sharing the same code, so that the copies returned by
+// Wrap, WithDetail and WithRetryAfter still match their sentinel.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *Error) Wrap(err error) *Error {
+	out := *e
+	out.err = err
+	return &out
+}
+
+// WithDetail returns a copy of e with a detail appended to its message.
+func (e *Error) WithDetail(detail string) *Error {
+	out := *e
+	out.Detail = detail
+	return &out
+}
+
+// WithRetryAfter returns a copy of e telling clients when to try again.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	out := *e
+	out.RetryAfter = d
+	return &out
+}
+
+// As returns the first domain error in the chain of err.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf returns the kind of err, Internal for errors that are not domain
+// errors.
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return Internal
+}
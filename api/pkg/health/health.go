@@ -0,0 +1,154 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+type Settings struct {
+	CheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	FailureTTL   time.Duration `env:"HEALTH_FAILURE_TTL" envDefault:"5s"`
+}
+
+// Check verifies that a dependency of the service is usable.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a check, Cached telling a failure was reported
+// by an earlier run.
+type Result struct {
+	Status  string
+	Latency time.Duration
+	Error   string
+	Cached  bool
+}
+
+type Report struct {
+	Status string
+	Checks map[string]Result
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs checks concurrently, each bounded by a timeout. Failures are
+// remembered for a while and reported again without running the check, so
+// that probes hammering a struggling dependency do not make matters worse.
+type Checker struct {
+	checks   []Check
+	settings Settings
+
+	mu       *sync.Mutex
+	failures map[string]failure
+}
+
+type failure struct {
+	result Result
+	until  time.Time
+}
+
+func NewChecker(settings Settings, checks ...Check) Checker {
+	return Checker{
+		checks:   checks,
+		settings: settings,
+		mu:       &sync.Mutex{},
+		failures: map[string]failure{},
+	}
+}
+
+func (c Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]Result, len(c.checks)),
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c Checker) run(ctx context.Context, check Check) Result {
+	if result, ok := c.cached(check.Name); ok {
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.settings.CheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// some clients do not honour contexts, the check is abandoned on timeout
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Status:  StatusOK,
+		Latency: time.Since(start),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+		c.remember(check.Name, result)
+	}
+	return result
+}
+
+func (c Checker) cached(name string) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, ok := c.failures[name]
+	if !ok || time.Now().After(f.until) {
+		delete(c.failures, name)
+		return Result{}, false
+	}
+
+	result := f.result
+	result.Cached = true
+	return result, true
+}
+
+func (c Checker) remember(name string, result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures[name] = failure{
+		result: result,
+		until:  time.Now().Add(c.settings.FailureTTL),
+	}
+}
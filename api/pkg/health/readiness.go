@@ -0,0 +1,23 @@
+package health
+
+import "sync/atomic"
+
+// Readiness tells whether the service accepts traffic. It is flipped when
+// shutting down so that load balancers stop routing requests to the instance
+// before its server stops.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Drain marks the service as not ready, for good.
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+func (r *Readiness) Ready() bool {
+	return !r.draining.Load()
+}
@@ -0,0 +1,120 @@
+package i18n
+
+// catalogue translates the messages of domain errors, keyed by their stable
+// code. English messages are the ones the errors are declared with.
+var catalogue = map[string]map[string]string{
+	"es": {
+		"internal_error":             "se produjo un error inesperado",
+		"validation_failed":          "la solicitud contiene campos no válidos",
+		"already_exists":             "el recurso ya existe",
+		"body_too_large":             "el cuerpo de la solicitud es demasiado grande",
+		"email_taken":                "el correo electrónico ya está en uso",
+		"forbidden":                  "rol insuficiente",
+		"invalid_access_token":       "token de acceso no válido o caducado",
+		"invalid_authorization":      "la cabecera de autorización debe contener un token bearer",
+		"invalid_body":               "cuerpo de la solicitud no válido",
+		"invalid_credentials":        "credenciales no válidas",
+		"invalid_data":               "datos no válidos",
+		"invalid_magic_link":         "enlace mágico no válido o caducado",
+		"invalid_otp":                "código de un solo uso no válido o caducado",
+		"invalid_parameter":          "parámetro no válido",
+		"invalid_passkey":            "llave de acceso no válida",
+		"invalid_password":           "contraseña incorrecta",
+		"invalid_recovery_code":      "código de recuperación no válido",
+		"invalid_token":              "token no válido o caducado",
+		"invalid_two_factor_code":    "código de doble factor no válido",
+		"invalid_verification_token": "token de verificación no válido o caducado",
+		"passkey_cloned":             "el contador de firmas de la llave de acceso retrocedió, el autenticador podría estar clonado",
+		"passkey_exists":             "la llave de acceso ya está registrada",
+		"passkey_not_found":          "llave de acceso no encontrada",
+		"phone_taken":                "el número de teléfono ya está en uso",
+		"reference_not_found":        "recurso referenciado no encontrado",
+		"session_not_found":          "sesión no encontrada",
+		"session_revoked":            "sesión revocada",
+		"token_expired":              "token caducado",
+		"token_revoked":              "token revocado",
+		"too_many_attempts":          "demasiados intentos, inténtalo más tarde",
+		"two_factor_enabled":         "la autenticación de doble factor ya está activada",
+		"two_factor_not_found":       "la autenticación de doble factor no está configurada",
+		"unauthenticated":            "se requiere autenticación",
+		"unsupported_media_type":     "el cuerpo de la solicitud debe ser application/json",
+		"user_not_found":             "usuario no encontrado",
+		"weak_password":              "la contraseña no cumple la política de contraseñas",
+	},
+	"fr": {
+		"internal_error":             "une erreur inattendue s'est produite",
+		"validation_failed":          "la requête contient des champs invalides",
+		"already_exists":             "la ressource existe déjà",
+		"body_too_large":             "le corps de la requête est trop volumineux",
+		"email_taken":                "cette adresse e-mail est déjà utilisée",
+		"forbidden":                  "rôle insuffisant",
+		"invalid_access_token":       "jeton d'accès invalide ou expiré",
+		"invalid_authorization":      "l'en-tête d'autorisation doit contenir un jeton bearer",
+		"invalid_body":               "corps de la requête invalide",
+		"invalid_credentials":        "identifiants invalides",
+		"invalid_data":               "données invalides",
+		"invalid_magic_link":         "lien magique invalide ou expiré",
+		"invalid_otp":                "code à usage unique invalide ou expiré",
+		"invalid_parameter":          "paramètre invalide",
+		"invalid_passkey":            "clé d'accès invalide",
+		"invalid_password":           "mot de passe incorrect",
+		"invalid_recovery_code":      "code de récupération invalide",
+		"invalid_token":              "jeton invalide ou expiré",
+		"invalid_two_factor_code":    "code à deux facteurs invalide",
+		"invalid_verification_token": "jeton de vérification invalide ou expiré",
+		"passkey_cloned":             "le compteur de signatures de la clé d'accès a reculé, l'authentificateur a peut-être été cloné",
+		"passkey_exists":             "clé d'accès déjà enregistrée",
+		"passkey_not_found":          "clé d'accès introuvable",
+		"phone_taken":                "ce numéro de téléphone est déjà utilisé",
+		"reference_not_found":        "ressource référencée introuvable",
+		"session_not_found":          "session introuvable",
+		"session_revoked":            "session révoquée",
+		"token_expired":              "jeton expiré",
+		"token_revoked":              "jeton révoqué",
+		"too_many_attempts":          "trop de tentatives, réessayez plus tard",
+		"two_factor_enabled":         "l'authentification à deux facteurs est déjà activée",
+		"two_factor_not_found":       "l'authentification à deux facteurs n'est pas configurée",
+		"unauthenticated":            "authentification requise",
+		"unsupported_media_type":     "le corps de la requête doit être au format application/json",
+		"user_not_found":             "utilisateur introuvable",
+		"weak_password":              "le mot de passe ne respecte pas la politique de mots de passe",
+	},
+	"pt": {
+		"internal_error":             "ocorreu um erro inesperado",
+		"validation_failed":          "o pedido contém campos inválidos",
+		"already_exists":             "o recurso já existe",
+		"body_too_large":             "o corpo do pedido é demasiado grande",
+		"email_taken":                "o email já está em uso",
+		"forbidden":                  "função insuficiente",
+		"invalid_access_token":       "token de acesso inválido ou expirado",
+		"invalid_authorization":      "o cabeçalho de autorização deve conter um token bearer",
+		"invalid_body":               "corpo do pedido inválido",
+		"invalid_credentials":        "credenciais inválidas",
+		"invalid_data":               "dados inválidos",
+		"invalid_magic_link":         "link mágico inválido ou expirado",
+		"invalid_otp":                "código de uso único inválido ou expirado",
+		"invalid_parameter":          "parâmetro inválido",
+		"invalid_passkey":            "chave de acesso inválida",
+		"invalid_password":           "palavra-passe incorreta",
+		"invalid_recovery_code":      "código de recuperação inválido",
+		"invalid_token":              "token inválido ou expirado",
+		"invalid_two_factor_code":    "código de dois fatores inválido",
+		"invalid_verification_token": "token de verificação inválido ou expirado",
+		"passkey_cloned":             "o contador de assinaturas da chave de acesso recuou, o autenticador pode ter sido clonado",
+		"passkey_exists":             "chave de acesso já registada",
+		"passkey_not_found":          "chave de acesso não encontrada",
+		"phone_taken":                "o número de telefone já está em uso",
+		"reference_not_found":        "recurso referenciado não encontrado",
+		"session_not_found":          "sessão não encontrada",
+		"session_revoked":            "sessão revogada",
+		"token_expired":              "token expirado",
+		"token_revoked":              "token revogado",
+		"too_many_attempts":          "demasiadas tentativas, tente novamente mais tarde",
+		"two_factor_enabled":         "a autenticação de dois fatores já está ativada",
+		"two_factor_not_found":       "a autenticação de dois fatores não está configurada",
+		"unauthenticated":            "autenticação necessária",
+		"unsupported_media_type":     "o corpo do pedido deve ser application/json",
+		"user_not_found":             "utilizador não encontrado",
+		"weak_password":              "a palavra-passe não cumpre a política de palavras-passe",
+	},
+}
@@ -0,0 +1,127 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/pt"
+	ut "github.com/go-playground/universal-translator"
+	"golang.org/x/text/language"
+)
+
+type Settings struct {
+	DefaultLocale   string   `env:"I18N_DEFAULT_LOCALE" envDefault:"en"`
+	FallbackLocales []string `env:"I18N_FALLBACK_LOCALES" envSeparator:","`
+}
+
+type contextKey struct{}
+
+// supported lists the locales messages are translated to, english being the
+// language the messages are written in.
+var supported = []locales.Translator{en.New(), es.New(), fr.New(), pt.New()}
+
+// Translator picks the translator matching the languages accepted by a
+// client, falling back to the configured chain and then to the default
+// locale.
+type Translator struct {
+	uni       *ut.UniversalTranslator
+	fallbacks []string
+}
+
+func New(settings Settings) (*Translator, error) {
+	var def locales.Translator
+	for _, locale := range supported {
+		if locale.Locale() == settings.DefaultLocale {
+			def = locale
+		}
+	}
+
+	if def == nil {
+		return nil, fmt.Errorf("i18n: unsupported default locale %q", settings.DefaultLocale)
+	}
+
+	uni := ut.New(def, supported...)
+
+	for _, fallback := range settings.FallbackLocales {
+		if _, found := uni.GetTranslator(fallback); !found {
+			return nil, fmt.Errorf("i18n: unsupported fallback locale %q", fallback)
+		}
+	}
+
+	for locale, messages := range catalogue {
+		trans, _ := uni.GetTranslator(locale)
+		for key, text := range messages {
+			if err := trans.Add(key, text, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Translator{
+		uni:       uni,
+		fallbacks: settings.FallbackLocales,
+	}, nil
+}
+
+// Negotiate returns the translator for an Accept-Language header. Every
+// accepted language is tried as is then without its region (pt-BR, pt),
+// by order of preference, before the fallback chain and the default locale.
+// Clients that state no preference get the default locale.
+func (t *Translator) Negotiate(acceptLanguage string) ut.Translator {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	if len(tags) == 0 {
+		return t.uni.GetFallback()
+	}
+
+	candidates := make([]string, 0, 2*len(tags)+len(t.fallbacks))
+	for _, tag := range tags {
+		candidates = append(candidates, strings.ReplaceAll(tag.String(), "-", "_"))
+		if base, confidence := tag.Base(); confidence != language.No {
+			candidates = append(candidates, base.String())
+		}
+	}
+	candidates = append(candidates, t.fallbacks...)
+
+	trans, _ := t.uni.FindTranslator(candidates...)
+	return trans
+}
+
+// Translators returns the translator of every supported locale.
+func (t *Translator) Translators() []ut.Translator {
+	out := make([]ut.Translator, 0, len(supported))
+	for _, locale := range supported {
+		trans, _ := t.uni.GetTranslator(locale.Locale())
+		out = append(out, trans)
+	}
+	return out
+}
+
+// Tag returns the BCP 47 tag of the locale of trans, e.g. pt-BR for pt_BR.
+func Tag(trans ut.Translator) string {
+	return strings.ReplaceAll(trans.Locale(), "_", "-")
+}
+
+func NewContext(ctx context.Context, trans ut.Translator) context.Context {
+	return context.WithValue(ctx, contextKey{}, trans)
+}
+
+// FromContext returns the translator negotiated for the current request.
+func FromContext(ctx context.Context) (ut.Translator, bool) {
+	trans, ok := ctx.Value(contextKey{}).(ut.Translator)
+	return trans, ok
+}
+
+// Message translates the message identified by key, returning fallback when
+// the locale has no translation for it (e.g. english, which messages are
+// written in).
+func Message(trans ut.Translator, key, fallback string) string {
+	if text, err := trans.T(key); err == nil {
+		return text
+	}
+	return fallback
+}
@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	pt_translations "github.com/go-playground/validator/v10/translations/pt"
+)
+
+var defaultTranslations = map[string]func(*validator.Validate, ut.Translator) error{
+	"en": en_translations.RegisterDefaultTranslations,
+	"es": es_translations.RegisterDefaultTranslations,
+	"fr": fr_translations.RegisterDefaultTranslations,
+	"pt": pt_translations.RegisterDefaultTranslations,
+}
+
+// ruleTranslations covers the rules missing from the validator translations
+// along with our custom ones.
+var ruleTranslations = map[string]map[string]string{
+	"en": {
+		"dob": "{0} must be a date formatted as YYYY-MM-DD",
+	},
+	"es": {
+		"dob":              "{0} debe ser una fecha con el formato AAAA-MM-DD",
+		"required_without": "{0} es obligatorio cuando falta {1}",
+	},
+	"fr": {
+		"dob":              "{0} doit être une date au format AAAA-MM-JJ",
+		"required_without": "{0} est obligatoire lorsque {1} est absent",
+		"e164":             "{0} doit être un numéro de téléphone au format E.164",
+	},
+	"pt": {
+		"dob":              "{0} deve ser uma data no formato AAAA-MM-DD",
+		"required_without": "{0} é obrigatório quando {1} está em falta",
+	},
+}
+
+// RegisterValidator registers the translations of the validation rules of v
+// for every supported locale, so that validator.FieldError.Translate can be
+// used with any negotiated translator.
+func (t *Translator) RegisterValidator(v *validator.Validate) error {
+	for _, trans := range t.Translators() {
+		register, ok := defaultTranslations[trans.Locale()]
+		if !ok {
+			return fmt.Errorf("i18n: no validator translations for %q", trans.Locale())
+		}
+
+		if err := register(v, trans); err != nil {
+			return err
+		}
+
+		for tag, text := range ruleTranslations[trans.Locale()] {
+			if err := v.RegisterTranslation(tag, trans, addRule(tag, text), translateRule); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func addRule(tag, text string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, text, true)
+	}
+}
+
+func translateRule(trans ut.Translator, fe validator.FieldError) string {
+	text, err := trans.T(fe.Tag(), fe.Field(), strings.ToLower(fe.Param()))
+	if err != nil {
+		return fe.Error()
+	}
+	return text
+}
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying entry, the logger of the
+// request being served.
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// WithFields returns a copy of ctx whose logger is enriched with fields,
+// e.g. the user id once the request is authenticated.
+func WithFields(ctx context.Context, l *logrus.Logger, fields logrus.Fields) context.Context {
+	return NewContext(ctx, FromContext(ctx, l).WithFields(fields))
+}
+
+// FromContext returns the logger carried by ctx, with the fields describing
+// the current request, or l when there is none.
+func FromContext(ctx context.Context, l *logrus.Logger) *logrus.Entry {
+	if entry, ok := ctx.Value(contextKey{}).(*logrus.Entry); ok {
+		return entry
+	}
+	return logrus.NewEntry(l)
+}
@@ -1,12 +1,68 @@
 package logger
 
 import (
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
 type Settings struct {
+	Level  string `env:"LOG_LEVEL" envDefault:"info"`
+	Format string `env:"LOG_FORMAT" envDefault:"text"`
+	// Output is stdout, stderr or the path of a file logs are appended to
+	Output string `env:"LOG_OUTPUT" envDefault:"stdout"`
+
+	// RedactFields masks the value of fields whose name contains one of them
+	RedactFields []string `env:"LOG_REDACT_FIELDS" envSeparator:"," envDefault:"password,token,secret,authorization,cookie,otp"`
+	RedactEmails bool     `env:"LOG_REDACT_EMAILS" envDefault:"true"`
+	RedactTokens bool     `env:"LOG_REDACT_TOKENS" envDefault:"true"`
+}
+
+func New(settings Settings) (*logrus.Logger, error) {
+	l := logrus.New()
+
+	level, err := logrus.ParseLevel(settings.Level)
+	if err != nil {
+		return nil, err
+	}
+	l.SetLevel(level)
+
+	var formatter logrus.Formatter
+	switch settings.Format {
+	case FormatText:
+		formatter = &logrus.TextFormatter{FullTimestamp: true}
+	case FormatJSON:
+		formatter = &logrus.JSONFormatter{}
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", settings.Format)
+	}
+	l.SetFormatter(newRedactor(formatter, settings))
+
+	out, err := output(settings.Output)
+	if err != nil {
+		return nil, err
+	}
+	l.SetOutput(out)
+
+	return l, nil
 }
 
-func New(settings Settings) *logrus.Logger {
-	return logrus.New()
+func output(name string) (io.Writer, error) {
+	switch name {
+	case OutputStdout:
+		return os.Stdout, nil
+	case OutputStderr:
+		return os.Stderr, nil
+	}
+	return os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 }
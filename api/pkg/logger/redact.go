@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// tokenPattern matches jwts and bearer credentials
+	tokenPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]*\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*|(?i:bearer)\s+\S+`)
+)
+
+// redactor keeps personal data and credentials out of the logs: the values of
+// fields named after a sensitive key are masked, and emails and tokens are
+// masked wherever they appear in messages and field values.
+type redactor struct {
+	next   logrus.Formatter
+	keys   []string
+	emails bool
+	tokens bool
+}
+
+func newRedactor(next logrus.Formatter, settings Settings) redactor {
+	keys := make([]string, 0, len(settings.RedactFields))
+	for _, key := range settings.RedactFields {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return redactor{
+		next:   next,
+		keys:   keys,
+		emails: settings.RedactEmails,
+		tokens: settings.RedactTokens,
+	}
+}
+
+func (r redactor) Format(entry *logrus.Entry) ([]byte, error) {
+	// the entry is copied, it may be shared with other formatters and hooks
+	e := *entry
+	e.Message = r.redact(entry.Message)
+	e.Data = make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		e.Data[key] = r.field(key, value)
+	}
+	return r.next.Format(&e)
+}
+
+func (r redactor) field(key string, value any) any {
+	if r.sensitive(key) {
+		return redacted
+	}
+
+	switch v := value.(type) {
+	case string:
+		return r.redact(v)
+	case error:
+		return r.redact(v.Error())
+	case fmt.Stringer:
+		return r.redact(v.String())
+	}
+	return value
+}
+
+func (r redactor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r redactor) redact(s string) string {
+	if r.tokens {
+		s = tokenPattern.ReplaceAllString(s, redacted)
+	}
+	if r.emails {
+		s = emailPattern.ReplaceAllString(s, redacted)
+	}
+	return s
+}
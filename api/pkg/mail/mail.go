@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	DriverSMTP   = "smtp"
+	DriverOutbox = "outbox"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Settings struct {
+	Driver       string `env:"MAIL_DRIVER" envDefault:"outbox"`
+	From         string `env:"MAIL_FROM" envDefault:"no-reply@muzz.com"`
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUser     string `env:"SMTP_USER"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	OutboxPath   string `env:"MAIL_OUTBOX_PATH" envDefault:"outbox"`
+}
+
+// New returns the sender matching the configured driver.
+func New(settings Settings) (Sender, error) {
+	switch settings.Driver {
+	case DriverSMTP:
+		return NewSMTPSender(settings), nil
+	case DriverOutbox:
+		return NewOutboxSender(settings)
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %s", settings.Driver)
+	}
+}
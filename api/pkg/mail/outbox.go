@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OutboxSender writes every message as an .eml file into a local directory
+// instead of delivering it, which is handy for local development and tests.
+type OutboxSender struct {
+	path string
+	from string
+}
+
+func NewOutboxSender(settings Settings) (OutboxSender, error) {
+	if err := os.MkdirAll(settings.OutboxPath, 0o755); err != nil {
+		return OutboxSender{}, err
+	}
+
+	return OutboxSender{
+		path: settings.OutboxPath,
+		from: settings.From,
+	}, nil
+}
+
+func (s OutboxSender) Send(ctx context.Context, msg Message) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), hex.EncodeToString(suffix))
+	return os.WriteFile(filepath.Join(s.path, name), encode(s.from, msg), 0o644)
+}
@@ -0,0 +1,31 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+type SMTPSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPSender(settings Settings) SMTPSender {
+	var auth smtp.Auth
+	if settings.SMTPUser != "" {
+		auth = smtp.PlainAuth("", settings.SMTPUser, settings.SMTPPassword, settings.SMTPHost)
+	}
+
+	return SMTPSender{
+		addr: net.JoinHostPort(settings.SMTPHost, strconv.Itoa(settings.SMTPPort)),
+		from: settings.From,
+		auth: auth,
+	}
+}
+
+func (s SMTPSender) Send(ctx context.Context, msg Message) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, msg.To, encode(s.from, msg))
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "muzz"
+
+// Metrics holds the collectors exposed on /metrics. It is registered on its
+// own registry, rather than the global one, so that only what is declared
+// here along with the go runtime and process stats is exposed.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.HistogramVec
+	usersCreated    prometheus.Counter
+	swipes          *prometheus.CounterVec
+	matches         prometheus.Counter
+	discoverResults prometheus.Histogram
+}
+
+func New() (*Metrics, error) {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the requests served, by route pattern and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "status"}),
+		usersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "users_created_total",
+			Help:      "Number of users created.",
+		}),
+		swipes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "swipes_total",
+			Help:      "Number of swipes, by preference.",
+		}, []string{"preference"}),
+		matches: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "matches_created_total",
+			Help:      "Number of matches created.",
+		}),
+		discoverResults: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "discover_results",
+			Help:      "Number of profiles returned by discover.",
+			Buckets:   []float64{0, 1, 5, 10, 25, 50, 100, 250, 500},
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.usersCreated,
+		m.swipes,
+		m.matches,
+		m.discoverResults,
+	} {
+		if err := m.registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Handler serves the metrics in the prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDB exposes the connection pool stats of db.
+func (m *Metrics) RegisterDB(name string, db *sql.DB) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterRedis exposes the connection pool stats of a redis client.
+func (m *Metrics) RegisterRedis(client *goredis.Client) error {
+	return m.registry.Register(newRedisCollector(client))
+}
+
+func (m *Metrics) ObserveRequest(route string, status int, latency time.Duration) {
+	m.requests.WithLabelValues(route, strconv.Itoa(status)).Observe(latency.Seconds())
+}
+
+func (m *Metrics) UserCreated() {
+	m.usersCreated.Inc()
+}
+
+// Swiped counts a swipe, labelled yes or no like the preference sent by
+// clients.
+func (m *Metrics) Swiped(preference bool) {
+	label := "no"
+	if preference {
+		label = "yes"
+	}
+	m.swipes.WithLabelValues(label).Inc()
+}
+
+func (m *Metrics) MatchCreated() {
+	m.matches.Inc()
+}
+
+func (m *Metrics) Discovered(results int) {
+	m.discoverResults.Observe(float64(results))
+}
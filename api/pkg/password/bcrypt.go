@@ -0,0 +1,40 @@
+package password
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(settings Settings) BcryptHasher {
+	return BcryptHasher{
+		cost: settings.BcryptCost,
+	}
+}
+
+func (h BcryptHasher) Hash(value string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(value), h.cost)
+	return string(bytes), err
+}
+
+func (h BcryptHasher) Compare(hashed, value string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(value))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+func (h BcryptHasher) NeedsRehash(hashed string) bool {
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return err != nil || cost != h.cost
+}
+
+func (h BcryptHasher) Owns(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
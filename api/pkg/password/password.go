@@ -0,0 +1,87 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match")
+	ErrUnknownFormat = errors.New("unknown password hash format")
+)
+
+// Hasher hashes passwords and checks them against stored hashes.
+type Hasher interface {
+	Hash(value string) (string, error)
+	Compare(hashed, value string) error
+	// NeedsRehash reports whether hashed was produced by another algorithm
+	// or with other parameters than the ones currently configured.
+	NeedsRehash(hashed string) bool
+}
+
+type Settings struct {
+	Algorithm         string `env:"PASSWORD_HASH_ALGORITHM" envDefault:"argon2id"`
+	Argon2Memory      uint32 `env:"ARGON2_MEMORY" envDefault:"65536"`
+	Argon2Iterations  uint32 `env:"ARGON2_ITERATIONS" envDefault:"3"`
+	Argon2Parallelism uint8  `env:"ARGON2_PARALLELISM" envDefault:"2"`
+	Argon2SaltLength  uint32 `env:"ARGON2_SALT_LENGTH" envDefault:"16"`
+	Argon2KeyLength   uint32 `env:"ARGON2_KEY_LENGTH" envDefault:"32"`
+	BcryptCost        int    `env:"BCRYPT_COST" envDefault:"10"`
+	MinLength         int    `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	MaxLength         int    `env:"PASSWORD_MAX_LENGTH" envDefault:"128"`
+	BreachedListPath  string `env:"PASSWORD_BREACHED_LIST_PATH"`
+}
+
+// New returns a hasher producing hashes with the configured algorithm while
+// still accepting hashes of every supported algorithm, so stored passwords
+// can be migrated as users log in.
+func New(settings Settings) (Hasher, error) {
+	argon := NewArgon2idHasher(settings)
+	bcrypt := NewBcryptHasher(settings)
+
+	switch settings.Algorithm {
+	case AlgorithmArgon2id:
+		return multiHasher{current: argon, others: []formatHasher{bcrypt}}, nil
+	case AlgorithmBcrypt:
+		return multiHasher{current: bcrypt, others: []formatHasher{argon}}, nil
+	default:
+		return nil, fmt.Errorf("unknown password hash algorithm: %s", settings.Algorithm)
+	}
+}
+
+// formatHasher is a hasher able to tell whether it produced a given hash.
+type formatHasher interface {
+	Hasher
+	Owns(hashed string) bool
+}
+
+type multiHasher struct {
+	current formatHasher
+	others  []formatHasher
+}
+
+func (h multiHasher) Hash(value string) (string, error) {
+	return h.current.Hash(value)
+}
+
+func (h multiHasher) Compare(hashed, value string) error {
+	if h.current.Owns(hashed) {
+		return h.current.Compare(hashed, value)
+	}
+
+	for _, other := range h.others {
+		if other.Owns(hashed) {
+			return other.Compare(hashed, value)
+		}
+	}
+	return ErrUnknownFormat
+}
+
+func (h multiHasher) NeedsRehash(hashed string) bool {
+	return !h.current.Owns(hashed) || h.current.NeedsRehash(hashed)
+}
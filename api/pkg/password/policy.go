@@ -0,0 +1,72 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/muzz/api/pkg/errs"
+)
+
+var (
+	ErrWeakPassword = errs.New(errs.Validation, "weak_password", "password does not meet the password policy")
+)
+
+// Policy decides which passwords are acceptable for new credentials.
+type Policy struct {
+	minLength int
+	maxLength int
+	breached  map[string]struct{}
+}
+
+// NewPolicy builds the policy from settings, loading the breached password
+// list (one password per line, # for comments) when a path is configured.
+func NewPolicy(settings Settings) (Policy, error) {
+	policy := Policy{
+		minLength: settings.MinLength,
+		maxLength: settings.MaxLength,
+		breached:  map[string]struct{}{},
+	}
+
+	if settings.BreachedListPath == "" {
+		return policy, nil
+	}
+
+	f, err := os.Open(settings.BreachedListPath)
+	if err != nil {
+		return Policy{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		policy.breached[strings.ToLower(line)] = struct{}{}
+	}
+
+	return policy, scanner.Err()
+}
+
+// Check returns an error wrapping ErrWeakPassword when value breaks a rule.
+func (p Policy) Check(value string) error {
+	length := utf8.RuneCountInString(value)
+
+	if length < p.minLength {
+		return ErrWeakPassword.WithDetail(fmt.Sprintf("it must be at least %d characters long", p.minLength))
+	}
+
+	if p.maxLength > 0 && length > p.maxLength {
+		return ErrWeakPassword.WithDetail(fmt.Sprintf("it must be at most %d characters long", p.maxLength))
+	}
+
+	if _, ok := p.breached[strings.ToLower(value)]; ok {
+		return ErrWeakPassword.WithDetail("it appears in a list of breached passwords")
+	}
+
+	return nil
+}
@@ -3,41 +3,213 @@ package pg
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
 
 	"github.com/pressly/goose/v3"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultMigrationPath is where new migrations are created when no
+// external directory is configured, relative to the api sources.
+const defaultMigrationPath = "migrations"
+
+// PgMigrationSettings configures the source of the migrations, Embedded
+// unless an external MigrationPath is set.
 type PgMigrationSettings struct {
 	MigrationPath string
+	Embedded      fs.FS
 	DataSource    string
 }
 
+// migrationLock is the key of the advisory lock serialising migrators, e.g.
+// replicas starting at once.
+const migrationLock int64 = 7236358434917331053
+
+// ErrUnknownMigrations is returned when the database has migrations applied
+// that the binary does not know about, e.g. after a rollback of the service.
+var ErrUnknownMigrations = errors.New("database has unknown migrations")
+
 type PgMigration struct {
 	db       *sql.DB
+	dir      string
 	settings PgMigrationSettings
 	logger   *logrus.Logger
 }
 
-func (m PgMigration) Up() error {
-	defer func() {
-		if err := m.Close(); err != nil {
-			m.logger.Fatal(context.Background(), "goose: failed to close DB: %v\n", nil)
+func (m PgMigration) Up(ctx context.Context) error {
+	return m.locked(ctx, func() error {
+		return goose.UpContext(ctx, m.db, m.dir)
+	})
+}
+
+// Down rolls back the last migration applied.
+func (m PgMigration) Down(ctx context.Context) error {
+	return m.locked(ctx, func() error {
+		return goose.DownContext(ctx, m.db, m.dir)
+	})
+}
+
+// Redo rolls back the last migration applied then applies it again.
+func (m PgMigration) Redo(ctx context.Context) error {
+	return m.locked(ctx, func() error {
+		return goose.RedoContext(ctx, m.db, m.dir)
+	})
+}
+
+// Status logs the migrations along with whether they were applied.
+func (m PgMigration) Status(ctx context.Context) error {
+	return goose.StatusContext(ctx, m.db, m.dir)
+}
+
+// Create writes a new empty sql migration called name into the external
+// migration directory, or the one embedded in the binary by default.
+func (m PgMigration) Create(name string) error {
+	dir := m.settings.MigrationPath
+	if dir == "" {
+		dir = defaultMigrationPath
+	}
+	return goose.Create(nil, dir, name, "sql")
+}
+
+// Latest returns the version of the newest migration.
+func (m PgMigration) Latest() (int64, error) {
+	migrations, err := goose.CollectMigrations(m.dir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, err
+	}
+	return last.Version, nil
+}
+
+// CheckUnknown fails with ErrUnknownMigrations when migrations missing from
+// the migration source were applied to the database.
+func (m PgMigration) CheckUnknown(ctx context.Context) error {
+	migrations, err := goose.CollectMigrations(m.dir, 0, goose.MaxVersion)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[int64]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+	}
+
+	if _, err := goose.EnsureDBVersionContext(ctx, m.db); err != nil {
+		return err
+	}
+
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT DISTINCT version_id FROM %s WHERE is_applied AND version_id > 0 ORDER BY version_id", goose.TableName(),
+	))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var unknown []string
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return err
 		}
-	}()
+		if !known[version] {
+			unknown = append(unknown, strconv.FormatInt(version, 10))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	return goose.Up(m.db, m.settings.MigrationPath)
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownMigrations, strings.Join(unknown, ", "))
+	}
+	return nil
 }
 
 func (m PgMigration) Close() error {
 	return m.db.Close()
 }
 
+// locked runs fn while holding the migration advisory lock. The lock is tied
+// to a connection, it is released if the process dies while holding it.
+func (m PgMigration) locked(ctx context.Context, fn func() error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLock).Scan(&acquired); err != nil {
+		return err
+	}
+
+	if !acquired {
+		m.logger.Info("waiting for another migration to complete")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+			return err
+		}
+	}
+
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLock); err != nil {
+			m.logger.WithError(err).Error("failed to release the migration lock")
+		}
+	}()
+
+	return fn()
+}
+
 func NewPgMigration(logger *logrus.Logger, settings PgMigrationSettings) (PgMigration, error) {
 	db, err := goose.OpenDBWithDriver("postgres", settings.DataSource)
 	if err != nil {
 		return PgMigration{}, err
 	}
 
-	return PgMigration{db: db, settings: settings, logger: logger}, nil
+	goose.SetLogger(gooseLogger{logger})
+
+	// goose reads migrations from a global file system
+	dir := "."
+	if settings.MigrationPath != "" {
+		goose.SetBaseFS(nil)
+		dir = settings.MigrationPath
+	} else {
+		goose.SetBaseFS(settings.Embedded)
+	}
+
+	return PgMigration{db: db, dir: dir, settings: settings, logger: logger}, nil
+}
+
+// gooseLogger strips the line feed goose ends its messages with.
+type gooseLogger struct {
+	*logrus.Logger
+}
+
+func (l gooseLogger) Printf(format string, v ...any) {
+	l.Logger.Print(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+// ErrMigrationMismatch is returned when the database is not migrated to the
+// newest migration known to the service.
+var ErrMigrationMismatch = errors.New("database migration version mismatch")
+
+// CheckMigrations fails unless the database is migrated to version latest.
+func (p Postgres) CheckMigrations(ctx context.Context, latest int64) error {
+	current, err := p.MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if current != latest {
+		return fmt.Errorf("%w: database at %d, expected %d", ErrMigrationMismatch, current, latest)
+	}
+	return nil
 }
@@ -1,10 +1,17 @@
 package pg
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/XSAM/otelsql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 type Postgres struct {
@@ -18,11 +25,21 @@ type PostgresSettings struct {
 }
 
 func NewPostgres(config PostgresSettings) (*Postgres, error) {
-	db, err := sqlx.Open("pgx", config.DataSource)
+	// every statement is traced, with its text but not its arguments
+	raw, err := otelsql.Open("pgx", config.DataSource,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
 		return nil, err
 	}
 
+	db := sqlx.NewDb(raw, "pgx")
+
 	db.SetMaxIdleConns(config.MaxIdleConns)
 	db.SetMaxOpenConns(config.MaxOpenConns)
 
@@ -40,3 +57,36 @@ func (p Postgres) Raw() *sql.DB {
 func (p Postgres) DBX() *sqlx.DB {
 	return p.db
 }
+
+// Pgx runs fn on a native pgx connection of the pool, for the postgres
+// features database/sql does not expose such as COPY.
+func (p Postgres) Pgx(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		// connections are wrapped by otelsql
+		if wrapped, ok := driverConn.(interface{ Raw() driver.Conn }); ok {
+			driverConn = wrapped.Raw()
+		}
+
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		return fn(c.Conn())
+	})
+}
+
+// MigrationVersion returns the version of the last migration applied to the
+// database.
+func (p Postgres) MigrationVersion(ctx context.Context) (int64, error) {
+	return goose.GetDBVersionContext(ctx, p.db.DB)
+}
+
+func (p Postgres) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
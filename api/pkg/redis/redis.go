@@ -1,6 +1,8 @@
 package redis
 
 import (
+	"context"
+
 	"github.com/go-redis/redis"
 )
 
@@ -21,3 +23,9 @@ func NewRedis(settings RedisSettings) (*Redis, error) {
 	client := redis.NewClient(opt)
 	return &Redis{client}, nil
 }
+
+// PingContext checks the connection to the server, the ping is traced as a
+// child of the span ctx carries.
+func (r *Redis) PingContext(ctx context.Context) error {
+	return r.WithContext(ctx).Ping().Err()
+}
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "github.com/muzz/api/pkg/redis"
+
+// WithContext returns a client bound to ctx whose commands are traced as
+// children of the span ctx carries. The key and arguments of commands are
+// not recorded since they may hold tokens.
+func (r *Redis) WithContext(ctx context.Context) *redis.Client {
+	client := r.Client.WithContext(ctx)
+	tracer := otel.Tracer(instrumentation)
+
+	client.WrapProcess(func(process func(cmd redis.Cmder) error) func(cmd redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			_, span := tracer.Start(ctx, "redis "+cmd.Name(),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("db.system", "redis"),
+					attribute.String("db.operation", cmd.Name()),
+				),
+			)
+			defer span.End()
+
+			return record(span, process(cmd))
+		}
+	})
+
+	client.WrapProcessPipeline(func(process func(cmds []redis.Cmder) error) func(cmds []redis.Cmder) error {
+		return func(cmds []redis.Cmder) error {
+			names := make([]string, 0, len(cmds))
+			for _, cmd := range cmds {
+				names = append(names, cmd.Name())
+			}
+
+			_, span := tracer.Start(ctx, "redis pipeline",
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("db.system", "redis"),
+					attribute.String("db.operation", strings.Join(names, " ")),
+				),
+			)
+			defer span.End()
+
+			return record(span, process(cmds))
+		}
+	})
+
+	return client
+}
+
+// record marks span as failed unless err is nil or a missing key.
+func record(span trace.Span, err error) error {
+	if err != nil && err != redis.Nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
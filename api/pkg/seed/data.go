@@ -0,0 +1,86 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// City is a center around which users are clustered, weighted by its
+// population in millions.
+type City struct {
+	Name   string
+	Lat    float64
+	Long   float64
+	Weight float64
+}
+
+// Cities are the cities known by name to ParseCities.
+var Cities = map[string]City{
+	"london":     {"london", 51.5072, -0.1276, 8.9},
+	"birmingham": {"birmingham", 52.4862, -1.8904, 1.1},
+	"manchester": {"manchester", 53.4808, -2.2426, 0.55},
+	"leeds":      {"leeds", 53.8008, -1.5491, 0.8},
+	"glasgow":    {"glasgow", 55.8642, -4.2518, 0.63},
+	"liverpool":  {"liverpool", 53.4084, -2.9916, 0.5},
+	"bristol":    {"bristol", 51.4545, -2.5879, 0.47},
+	"edinburgh":  {"edinburgh", 55.9533, -3.1883, 0.52},
+	"cardiff":    {"cardiff", 51.4816, -3.1791, 0.36},
+	"belfast":    {"belfast", 54.5973, -5.9301, 0.35},
+}
+
+// ParseCities parses a comma separated list of city names, or of lat:long
+// coordinates for places not in Cities.
+func ParseCities(value string) ([]City, error) {
+	var out []City
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if city, ok := Cities[name]; ok {
+			out = append(out, city)
+			continue
+		}
+
+		lat, long, ok := strings.Cut(name, ":")
+		if !ok {
+			return nil, fmt.Errorf("unknown city %q", name)
+		}
+
+		city := City{Name: name, Weight: 1}
+		var err error
+		if city.Lat, err = strconv.ParseFloat(lat, 64); err != nil || city.Lat < -90 || city.Lat > 90 {
+			return nil, fmt.Errorf("invalid latitude in %q", name)
+		}
+		if city.Long, err = strconv.ParseFloat(long, 64); err != nil || city.Long < -180 || city.Long > 180 {
+			return nil, fmt.Errorf("invalid longitude in %q", name)
+		}
+		out = append(out, city)
+	}
+
+	if len(out) == 0 {
+		return nil, errors.New("no city given")
+	}
+	return out, nil
+}
+
+var (
+	femaleNames = []string{
+		"Olivia", "Amelia", "Isla", "Ava", "Mia", "Ivy", "Lily", "Isabella", "Rosie", "Sophia",
+		"Grace", "Freya", "Emily", "Poppy", "Ella", "Evie", "Charlotte", "Aisha", "Fatima", "Maryam",
+		"Zara", "Hannah", "Sarah", "Chloe", "Priya", "Jessica", "Lucy", "Ruby", "Layla", "Sofia",
+	}
+	maleNames = []string{
+		"Muhammad", "Noah", "Oliver", "George", "Leo", "Arthur", "Oscar", "Harry", "Jack", "Charlie",
+		"Theo", "Freddie", "Henry", "Thomas", "James", "William", "Alfie", "Joshua", "Adam", "Yusuf",
+		"Ibrahim", "Omar", "Ali", "Daniel", "Samuel", "Ryan", "Ethan", "Liam", "Aryan", "Jacob",
+	}
+	lastNames = []string{
+		"Smith", "Jones", "Williams", "Taylor", "Brown", "Davies", "Evans", "Wilson", "Thomas", "Johnson",
+		"Roberts", "Robinson", "Thompson", "Wright", "Walker", "White", "Edwards", "Hughes", "Green", "Hall",
+		"Khan", "Ahmed", "Ali", "Hussain", "Patel", "Begum", "Shah", "Singh", "Campbell", "Murphy",
+	}
+)
@@ -0,0 +1,264 @@
+// Package seed generates synthetic populations of users along with their
+// swipes and matches, for exercising discovery at scale.
+package seed
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// share of the swipes on users of the same city, the others go to any user
+	localSwipes = 0.8
+	// share of the swipes on users of the other gender
+	oppositeSwipes = 0.9
+	// swipes are spread over the days before Settings.Now
+	swipeWindow = 30 * 24 * time.Hour
+)
+
+type Settings struct {
+	Users  int
+	Seed   uint64
+	Cities []City
+	// Spread is the standard deviation in km of the distance of the users
+	// to the center of their city.
+	Spread float64
+	// FemaleRatio is the share of the users with the F gender.
+	FemaleRatio float64
+	// VerifiedRatio is the share of the users with a verified email.
+	VerifiedRatio float64
+	// Swipes is the average number of swipes by user.
+	Swipes int
+	// LikeRatio is the chance of a swipe being a like for a user of average
+	// attractiveness.
+	LikeRatio float64
+	// Reciprocity is the chance of a swiped user swiping back.
+	Reciprocity float64
+	// Now is the date ages and swipes are relative to.
+	Now time.Time
+}
+
+type User struct {
+	Email         string
+	Name          string
+	Gender        string
+	DOB           time.Time
+	LocationLat   float64
+	LocationLong  float64
+	EmailVerified bool
+}
+
+// Swipe and Match refer to users by their index in Population.Users.
+type Swipe struct {
+	User        int
+	SwipedUser  int
+	SwipeStatus bool
+	CreatedAt   time.Time
+}
+
+type Match struct {
+	User1     int
+	User2     int
+	CreatedAt time.Time
+}
+
+type Population struct {
+	Users   []User
+	Swipes  []Swipe
+	Matches []Match
+}
+
+// Generate returns a population generated from settings, the same settings
+// always resulting in the same population.
+func Generate(settings Settings) Population {
+	r := rand.New(rand.NewPCG(settings.Seed, 0))
+
+	g := generator{
+		r:        r,
+		settings: settings,
+		members:  make([]map[string][]int, len(settings.Cities)),
+	}
+	for i := range g.members {
+		g.members[i] = map[string][]int{}
+	}
+
+	g.users()
+	g.swipes()
+	g.matches()
+
+	return g.population
+}
+
+type generator struct {
+	r          *rand.Rand
+	settings   Settings
+	population Population
+	// city and appeal of every user
+	city   []int
+	appeal []float64
+	// users of every city by gender
+	members []map[string][]int
+}
+
+func (g *generator) users() {
+	var total float64
+	for _, c := range g.settings.Cities {
+		total += c.Weight
+	}
+
+	var appeal float64
+	for i := 0; i < g.settings.Users; i++ {
+		city := g.pickCity(total)
+
+		gender, first := "M", maleNames[g.r.IntN(len(maleNames))]
+		if g.r.Float64() < g.settings.FemaleRatio {
+			gender, first = "F", femaleNames[g.r.IntN(len(femaleNames))]
+		}
+		last := lastNames[g.r.IntN(len(lastNames))]
+
+		lat, long := g.around(g.settings.Cities[city])
+
+		g.population.Users = append(g.population.Users, User{
+			Email:         strings.ToLower(fmt.Sprintf("%s.%s.%d.%d@seed.example.com", first, last, g.settings.Seed, i)),
+			Name:          first + " " + last,
+			Gender:        gender,
+			DOB:           g.dob(),
+			LocationLat:   lat,
+			LocationLong:  long,
+			EmailVerified: g.r.Float64() < g.settings.VerifiedRatio,
+		})
+
+		g.city = append(g.city, city)
+		g.members[city][gender] = append(g.members[city][gender], i)
+
+		// a long tailed attractiveness, a few users getting most of the likes
+		g.appeal = append(g.appeal, math.Exp(g.r.NormFloat64()*0.5))
+		appeal += g.appeal[i]
+	}
+
+	// normalized so that the average user is liked at LikeRatio
+	for i := range g.appeal {
+		g.appeal[i] *= float64(len(g.appeal)) / appeal
+	}
+}
+
+func (g *generator) pickCity(total float64) int {
+	n := g.r.Float64() * total
+	for i, c := range g.settings.Cities {
+		if n < c.Weight {
+			return i
+		}
+		n -= c.Weight
+	}
+	return len(g.settings.Cities) - 1
+}
+
+// around returns a point at a normally distributed distance of the center
+// of city.
+func (g *generator) around(city City) (float64, float64) {
+	distance := math.Abs(g.r.NormFloat64()) * g.settings.Spread
+	bearing := g.r.Float64() * 2 * math.Pi
+
+	lat := city.Lat + distance*math.Cos(bearing)/earthRadiusKm*180/math.Pi
+	long := city.Long + distance*math.Sin(bearing)/(earthRadiusKm*math.Cos(city.Lat*math.Pi/180))*180/math.Pi
+
+	return math.Max(-90, math.Min(90, lat)), math.Mod(long+540, 360) - 180
+}
+
+// dob returns a date of birth of an adult, most users being in their
+// twenties and thirties.
+func (g *generator) dob() time.Time {
+	age := math.Max(18, math.Min(70, 30+g.r.NormFloat64()*7))
+	days := int(age * 365.25)
+
+	return g.settings.Now.AddDate(0, 0, -days).Truncate(24 * time.Hour)
+}
+
+func (g *generator) swipes() {
+	if len(g.population.Users) < 2 {
+		return
+	}
+
+	swiped := map[[2]int]bool{}
+	swipe := func(user, target int, at time.Time) {
+		if user == target || swiped[[2]int{user, target}] {
+			return
+		}
+		swiped[[2]int{user, target}] = true
+
+		g.population.Swipes = append(g.population.Swipes, Swipe{
+			User:        user,
+			SwipedUser:  target,
+			SwipeStatus: g.r.Float64() < g.settings.LikeRatio*g.appeal[target],
+			CreatedAt:   at,
+		})
+	}
+
+	for user := range g.population.Users {
+		for n := g.r.IntN(2*g.settings.Swipes + 1); n > 0; n-- {
+			target := g.pickTarget(user)
+			at := g.settings.Now.Add(-time.Duration(g.r.Int64N(int64(swipeWindow))))
+			swipe(user, target, at)
+
+			if g.r.Float64() < g.settings.Reciprocity {
+				swipe(target, user, at.Add(time.Duration(g.r.Int64N(int64(g.settings.Now.Sub(at))+1))))
+			}
+		}
+	}
+}
+
+func (g *generator) pickTarget(user int) int {
+	if g.r.Float64() >= localSwipes {
+		return g.r.IntN(len(g.population.Users))
+	}
+
+	gender := g.population.Users[user].Gender
+	if g.r.Float64() < oppositeSwipes {
+		gender = opposite(gender)
+	}
+
+	candidates := g.members[g.city[user]][gender]
+	if len(candidates) == 0 {
+		return g.r.IntN(len(g.population.Users))
+	}
+	return candidates[g.r.IntN(len(candidates))]
+}
+
+// matches pairs the mutual likes, the match belonging to the user who liked
+// last as it does through the api.
+func (g *generator) matches() {
+	likes := map[[2]int]time.Time{}
+	for _, s := range g.population.Swipes {
+		if s.SwipeStatus {
+			likes[[2]int{s.User, s.SwipedUser}] = s.CreatedAt
+		}
+	}
+
+	for _, s := range g.population.Swipes {
+		if !s.SwipeStatus {
+			continue
+		}
+
+		back, ok := likes[[2]int{s.SwipedUser, s.User}]
+		if !ok || back.After(s.CreatedAt) || (back.Equal(s.CreatedAt) && s.User > s.SwipedUser) {
+			continue
+		}
+
+		g.population.Matches = append(g.population.Matches, Match{
+			User1:     s.User,
+			User2:     s.SwipedUser,
+			CreatedAt: s.CreatedAt,
+		})
+	}
+}
+
+func opposite(gender string) string {
+	if gender == "M" {
+		return "F"
+	}
+	return "M"
+}
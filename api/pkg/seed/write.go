@@ -0,0 +1,75 @@
+package seed
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/muzz/api/pkg/pg"
+)
+
+// Write inserts the population in a single transaction with COPY, every user
+// getting the hashed password.
+func Write(ctx context.Context, db *pg.Postgres, population Population, hashed string) error {
+	return db.Pgx(ctx, func(conn *pgx.Conn) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		// COPY does not return the generated ids, they are reserved up front
+		// so that swipes and matches can refer to the users
+		ids := make([]int64, 0, len(population.Users))
+		rows, err := tx.Query(ctx, `SELECT nextval(pg_get_serial_sequence('users', 'id')) FROM generate_series(1, $1)`, len(population.Users))
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		users := population.Users
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"users"},
+			[]string{"id", "email", "password", "name", "gender", "date_of_birth", "location_lat", "location_long", "email_verified"},
+			pgx.CopyFromSlice(len(users), func(i int) ([]any, error) {
+				u := users[i]
+				return []any{ids[i], u.Email, hashed, u.Name, u.Gender, u.DOB, u.LocationLat, u.LocationLong, u.EmailVerified}, nil
+			}),
+		); err != nil {
+			return err
+		}
+
+		swipes := population.Swipes
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"user_swipes"},
+			[]string{"user_id", "swiped_user_id", "swipe_status", "created_at"},
+			pgx.CopyFromSlice(len(swipes), func(i int) ([]any, error) {
+				s := swipes[i]
+				return []any{ids[s.User], ids[s.SwipedUser], s.SwipeStatus, s.CreatedAt}, nil
+			}),
+		); err != nil {
+			return err
+		}
+
+		matches := population.Matches
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{"matches"},
+			[]string{"user1_id", "user2_id", "created_at"},
+			pgx.CopyFromSlice(len(matches), func(i int) ([]any, error) {
+				m := matches[i]
+				return []any{ids[m.User1], ids[m.User2], m.CreatedAt}, nil
+			}),
+		); err != nil {
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+}
@@ -0,0 +1,51 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LogSender appends every message as a json line to a local file instead of
+// delivering it, which is handy for local development and tests.
+type LogSender struct {
+	mu   *sync.Mutex
+	path string
+}
+
+func NewLogSender(settings Settings) (LogSender, error) {
+	if err := os.MkdirAll(filepath.Dir(settings.LogPath), 0o755); err != nil {
+		return LogSender{}, err
+	}
+
+	return LogSender{
+		mu:   &sync.Mutex{},
+		path: settings.LogPath,
+	}, nil
+}
+
+func (s LogSender) Send(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(struct {
+		Time time.Time `json:"time"`
+		To   string    `json:"to"`
+		Body string    `json:"body"`
+	}{time.Now().UTC(), msg.To, msg.Body})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,35 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	DriverLog = "log"
+)
+
+type Message struct {
+	To   string
+	Body string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Settings struct {
+	Driver  string `env:"SMS_DRIVER" envDefault:"log"`
+	LogPath string `env:"SMS_LOG_PATH" envDefault:"outbox/sms.log"`
+}
+
+// New returns the sender matching the configured driver. Real providers are
+// plugged in by adding a driver here.
+func New(settings Settings) (Sender, error) {
+	switch settings.Driver {
+	case DriverLog:
+		return NewLogSender(settings)
+	default:
+		return nil, fmt.Errorf("unknown sms driver: %s", settings.Driver)
+	}
+}
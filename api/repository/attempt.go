@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"github.com/muzz/api/pkg/tracing"
+)
+
+// CountAttempt increments the attempt counter of key and returns its new
+// value. The counter resets once window has elapsed since the first attempt.
+func (a AuthRepo) CountAttempt(ctx context.Context, key string, window time.Duration) (int64, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.CountAttempt")
+	defer span.End()
+
+	// the counter is created with its expiry in the same transaction, so that
+	// it can never be left without one
+	var count *goredis.IntCmd
+	_, err := a.cache.WithContext(ctx).TxPipelined(func(pipe goredis.Pipeliner) error {
+		pipe.SetNX(attemptKey(key), 0, window)
+		count = pipe.Incr(attemptKey(key))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
+
+func (a AuthRepo) GetAttempts(ctx context.Context, key string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.GetAttempts")
+	defer span.End()
+
+	count, err := a.cache.WithContext(ctx).Get(attemptKey(key)).Int64()
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ResetAttempts clears both the attempt counter and any block on key.
+func (a AuthRepo) ResetAttempts(ctx context.Context, key string) error {
+	ctx, span := tracing.Start(ctx, "AuthRepo.ResetAttempts")
+	defer span.End()
+
+	return a.cache.WithContext(ctx).Del(attemptKey(key), blockKey(key)).Err()
+}
+
+func (a AuthRepo) Block(ctx context.Context, key string, ttl time.Duration) error {
+	ctx, span := tracing.Start(ctx, "AuthRepo.Block")
+	defer span.End()
+
+	return a.cache.WithContext(ctx).Set(blockKey(key), 1, ttl).Err()
+}
+
+// BlockedFor returns how long key remains blocked, zero when it is not.
+func (a AuthRepo) BlockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.BlockedFor")
+	defer span.End()
+
+	ttl, err := a.cache.WithContext(ctx).TTL(blockKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// missing keys are reported with a negative ttl
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func attemptKey(key string) string {
+	return fmt.Sprintf("attempts:%s", key)
+}
+
+func blockKey(key string) string {
+	return fmt.Sprintf("blocked:%s", key)
+}
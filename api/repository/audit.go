@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/muzz/api/pkg/pg"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditConnector gives append-only access to the audit log, events are
+// never updated nor deleted.
+type AuditConnector interface {
+	CreateAuditEvent(ctx context.Context, in model.AuditEventInput) error
+	GetAuditEvents(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error)
+}
+
+type AuditRepo struct {
+	l  *logrus.Logger
+	db *pg.Postgres
+}
+
+func NewAuditRepo(l *logrus.Logger, db *pg.Postgres) AuditRepo {
+	return AuditRepo{
+		l:  l,
+		db: db,
+	}
+}
+
+func (r AuditRepo) CreateAuditEvent(ctx context.Context, in model.AuditEventInput) error {
+	ctx, span := tracing.Start(ctx, "AuditRepo.CreateAuditEvent")
+	defer span.End()
+
+	if len(in.Details) == 0 {
+		in.Details = []byte("{}")
+	}
+
+	query := `INSERT INTO audit_events (event_type, actor_id, user_id, ip, user_agent, details)
+              VALUES (:event_type, :actor_id, :user_id, :ip, :user_agent, :details)`
+
+	_, err := r.db.DBX().NamedExecContext(ctx, query, in)
+	return err
+}
+
+// GetAuditEvents returns the events matching filter, newest first. Filtering
+// by user matches the events the user was either the actor or the subject of.
+func (r AuditRepo) GetAuditEvents(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	ctx, span := tracing.Start(ctx, "AuditRepo.GetAuditEvents")
+	defer span.End()
+
+	query := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Select("*").
+		From("audit_events").
+		OrderBy("created_at DESC", "id DESC").
+		Limit(filter.Limit)
+
+	if filter.UserID != nil {
+		query = query.Where(sq.Or{
+			sq.Eq{"user_id": *filter.UserID},
+			sq.Eq{"actor_id": *filter.UserID},
+		})
+	}
+
+	if filter.From != nil {
+		query = query.Where(sq.GtOrEq{"created_at": *filter.From})
+	}
+
+	if filter.To != nil {
+		query = query.Where(sq.Lt{"created_at": *filter.To})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	out := []model.AuditEvent{}
+	if err := r.db.DBX().SelectContext(ctx, &out, sql, args...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
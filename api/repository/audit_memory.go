@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryAuditRepo is an AuditConnector keeping the audit log in memory
+// rather than in postgres.
+type MemoryAuditRepo struct {
+	l   *logrus.Logger
+	log *auditLog
+}
+
+type auditLog struct {
+	mu sync.RWMutex
+	// events in insertion order, an event's id is its index plus one
+	events []model.AuditEvent
+}
+
+func NewMemoryAuditRepo(l *logrus.Logger) MemoryAuditRepo {
+	return MemoryAuditRepo{
+		l:   l,
+		log: &auditLog{},
+	}
+}
+
+func (r MemoryAuditRepo) CreateAuditEvent(ctx context.Context, in model.AuditEventInput) error {
+	_, span := tracing.Start(ctx, "MemoryAuditRepo.CreateAuditEvent")
+	defer span.End()
+
+	details := append([]byte(nil), in.Details...)
+	if len(details) == 0 {
+		details = []byte("{}")
+	}
+
+	r.log.mu.Lock()
+	defer r.log.mu.Unlock()
+
+	r.log.events = append(r.log.events, model.AuditEvent{
+		ID:        int64(len(r.log.events)) + 1,
+		Type:      in.Type,
+		ActorID:   clone(in.ActorID),
+		UserID:    clone(in.UserID),
+		IP:        clone(in.IP),
+		UserAgent: clone(in.UserAgent),
+		Details:   details,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+// GetAuditEvents returns the events matching filter, newest first. Filtering
+// by user matches the events the user was either the actor or the subject of.
+func (r MemoryAuditRepo) GetAuditEvents(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	_, span := tracing.Start(ctx, "MemoryAuditRepo.GetAuditEvents")
+	defer span.End()
+
+	r.log.mu.RLock()
+	defer r.log.mu.RUnlock()
+
+	out := []model.AuditEvent{}
+	for i := len(r.log.events) - 1; i >= 0 && uint64(len(out)) < filter.Limit; i-- {
+		e := r.log.events[i]
+
+		if filter.UserID != nil && value(e.UserID) != *filter.UserID && value(e.ActorID) != *filter.UserID {
+			continue
+		}
+
+		if filter.From != nil && e.CreatedAt.Before(*filter.From) {
+			continue
+		}
+
+		if filter.To != nil && !e.CreatedAt.Before(*filter.To) {
+			continue
+		}
+
+		out = append(out, e)
+	}
+	return out, nil
+}
@@ -2,95 +2,227 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	goredis "github.com/go-redis/redis"
 	"github.com/golang-jwt/jwt"
 	"github.com/mitchellh/mapstructure"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/password"
 	"github.com/muzz/api/pkg/redis"
+	"github.com/muzz/api/pkg/tracing"
 	"github.com/muzz/api/repository/model"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 )
 
-//go:generate mockgen -destination=./mocks/mock_user_connector.go -package=mocks github.com/muzz/api/repository UserConnector
 type AuthConnector interface {
 	HashPassword(value string) (string, error)
 	ValidateHash(hashed, value string) error
-	GenerateToken(ctx context.Context, uid int) (model.Token, error)
+	NeedsRehash(hashed string) bool
+	GenerateToken(ctx context.Context, uid int, role string, session model.Session) (model.Token, error)
 	GetTokenClaims(ctx context.Context, token string, out any) error
+	GenerateVerificationToken(ctx context.Context, uid int, email string, ttl time.Duration) (string, error)
+	ConsumeVerificationToken(ctx context.Context, token string) (model.VerificationClaims, error)
+	GenerateMagicLinkToken(ctx context.Context, uid int, email, fingerprint string, ttl time.Duration) (string, error)
+	ConsumeMagicLinkToken(ctx context.Context, token, fingerprint string) (model.VerificationClaims, error)
+	IssueOneTimeToken(ctx context.Context, purpose, value string, ttl time.Duration) (string, error)
+	PeekOneTimeToken(ctx context.Context, purpose, token string) (string, error)
+	ConsumeOneTimeToken(ctx context.Context, purpose, token string) (string, error)
+	ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	IssueOTP(ctx context.Context, key string, ttl time.Duration) (string, error)
+	ConsumeOTP(ctx context.Context, key, code string) error
+	DiscardOTP(ctx context.Context, key string) error
+	RevokeTokens(ctx context.Context, uid int) error
+	GetSessions(ctx context.Context, uid int) ([]model.Session, error)
+	TouchSession(ctx context.Context, id string, expires int64) error
+	DeleteSession(ctx context.Context, uid int, id string) error
+	CountAttempt(ctx context.Context, key string, window time.Duration) (int64, error)
+	GetAttempts(ctx context.Context, key string) (int64, error)
+	ResetAttempts(ctx context.Context, key string) error
+	Block(ctx context.Context, key string, ttl time.Duration) error
+	BlockedFor(ctx context.Context, key string) (time.Duration, error)
 }
 
-type AuthRepo struct {
-	l      *logrus.Logger
-	cache  *redis.Redis
+const tokenTTL = time.Minute * 30
+
+var (
+	ErrTokenExpired = errs.New(errs.Unauthorized, "token_expired", "token expired")
+	ErrTokenRevoked = errs.New(errs.Unauthorized, "token_revoked", "token revoked")
+)
+
+// tokens signs and hashes on behalf of the auth repositories, whichever
+// store they keep their state in.
+type tokens struct {
+	hasher password.Hasher
 	secret string
 }
 
-func NewAuthRepo(l *logrus.Logger, cache *redis.Redis, secret string) AuthRepo {
+type AuthRepo struct {
+	tokens
+	l     *logrus.Logger
+	cache *redis.Redis
+}
+
+func NewAuthRepo(l *logrus.Logger, cache *redis.Redis, hasher password.Hasher, secret string) AuthRepo {
 	return AuthRepo{
+		tokens: tokens{hasher: hasher, secret: secret},
 		l:      l,
 		cache:  cache,
-		secret: secret,
 	}
 }
 
-func (a AuthRepo) GenerateToken(ctx context.Context, uid int) (model.Token, error) {
-	secretKey := []byte(a.secret)
-	expires := time.Now().Add(time.Minute * 30).Unix()
+// GenerateToken signs a session token for uid and records the session, so
+// that it shows up in the user's session list and can be revoked on its own.
+// The device details are taken from session.
+func (a AuthRepo) GenerateToken(ctx context.Context, uid int, role string, session model.Session) (model.Token, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.GenerateToken")
+	defer span.End()
 
-	var claims jwt.MapClaims
+	now := time.Now()
+	expires := now.Add(tokenTTL).Unix()
 
-	err := mapstructure.Decode(model.TokenClaims{
-		UserID:     uid,
-		Authorized: true,
-		Expires:    expires,
-	}, &claims)
+	session.UserID = uid
+	session.CreatedAt = now
+	session.ExpiresAt = time.Unix(expires, 0)
 
+	session, err := a.createSession(ctx, session)
 	if err != nil {
 		return model.Token{}, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(secretKey)
+	return a.signSession(session, role)
+}
+
+// signSession signs the token of session, issued at its creation time.
+func (t tokens) signSession(session model.Session, role string) (model.Token, error) {
+	expires := session.ExpiresAt.Unix()
+
+	signed, err := t.signToken(model.TokenClaims{
+		UserID:     session.UserID,
+		SessionID:  session.ID,
+		Authorized: true,
+		Role:       role,
+		IssuedAt:   session.CreatedAt.UnixMilli(),
+		Expires:    expires,
+	})
 	if err != nil {
 		return model.Token{}, err
 	}
 
 	return model.Token{
-		UID:     uid,
+		UID:     session.UserID,
 		Token:   signed,
 		Expires: expires,
 	}, nil
 }
 
-func (s AuthRepo) ValidateHash(hashed, value string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(value))
+func (t tokens) ValidateHash(hashed, value string) error {
+	return t.hasher.Compare(hashed, value)
+}
+
+func (t tokens) HashPassword(value string) (string, error) {
+	return t.hasher.Hash(value)
 }
 
-func (a AuthRepo) HashPassword(value string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(value), bcrypt.DefaultCost)
-	return string(bytes), err
+// NeedsRehash reports whether hashed should be replaced by a hash made with
+// the current algorithm and parameters.
+func (t tokens) NeedsRehash(hashed string) bool {
+	return t.hasher.NeedsRehash(hashed)
 }
 
 func (a AuthRepo) GetTokenClaims(ctx context.Context, tokenStr string, out any) error {
-	fmt.Printf("Claims: %+v\n", out)
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(a.secret), nil
+	ctx, span := tracing.Start(ctx, "AuthRepo.GetTokenClaims")
+	defer span.End()
+
+	return a.decodeClaims(tokenStr, out, func(claims model.TokenClaims) error {
+		return a.checkSession(ctx, claims)
 	})
+}
 
+// decodeClaims decodes the claims of tokenStr into out. Session tokens must
+// not be expired and must pass check, which looks up their revocation.
+func (t tokens) decodeClaims(tokenStr string, out any, check func(claims model.TokenClaims) error) error {
+	claims, err := t.parseToken(tokenStr)
 	if err != nil {
 		return err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		err := mapstructure.Decode(claims, &out)
-		if err != nil {
+	var session model.TokenClaims
+	if err := mapstructure.Decode(claims, &session); err != nil {
+		return err
+	}
+
+	if session.Authorized {
+		if session.Expires < time.Now().Unix() {
+			return ErrTokenExpired
+		}
+
+		if err := check(session); err != nil {
 			return err
 		}
 	}
+
+	return mapstructure.Decode(claims, &out)
+}
+
+// RevokeTokens invalidates every session token issued to uid so far.
+func (a AuthRepo) RevokeTokens(ctx context.Context, uid int) error {
+	ctx, span := tracing.Start(ctx, "AuthRepo.RevokeTokens")
+	defer span.End()
+
+	// tokens outlive the marker by at most tokenTTL, after which they expire anyway
+	if err := a.cache.WithContext(ctx).Set(revokedKey(uid), time.Now().UnixMilli(), tokenTTL).Err(); err != nil {
+		return err
+	}
+	return a.deleteSessions(ctx, uid)
+}
+
+func (a AuthRepo) checkSession(ctx context.Context, claims model.TokenClaims) error {
+	revokedAt, err := a.cache.WithContext(ctx).Get(revokedKey(claims.UserID)).Int64()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return nil
+		}
+		return err
+	}
+
+	if claims.IssuedAt <= revokedAt {
+		return ErrTokenRevoked
+	}
 	return nil
 }
+
+func revokedKey(uid int) string {
+	return fmt.Sprintf("revoked_before:%d", uid)
+}
+
+func (t tokens) signToken(in any) (string, error) {
+	var claims jwt.MapClaims
+	if err := mapstructure.Decode(in, &claims); err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(t.secret))
+}
+
+func (t tokens) parseToken(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(t.secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/subtle"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/muzz/api/pkg/password"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryAuthRepo is an AuthConnector keeping its tokens, sessions and
+// attempt counters in memory rather than in redis. The state is lost on
+// restart and not shared between replicas.
+type MemoryAuthRepo struct {
+	tokens
+	l     *logrus.Logger
+	store *memoryStore
+}
+
+func NewMemoryAuthRepo(l *logrus.Logger, hasher password.Hasher, secret string) MemoryAuthRepo {
+	return MemoryAuthRepo{
+		tokens: tokens{hasher: hasher, secret: secret},
+		l:      l,
+		store:  newMemoryStore(),
+	}
+}
+
+func (a MemoryAuthRepo) GenerateToken(ctx context.Context, uid int, role string, session model.Session) (model.Token, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.GenerateToken")
+	defer span.End()
+
+	id, err := randomHex(16)
+	if err != nil {
+		return model.Token{}, err
+	}
+
+	now := time.Now()
+
+	session.ID = id
+	session.UserID = uid
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	session.ExpiresAt = time.Unix(now.Add(tokenTTL).Unix(), 0)
+
+	a.store.mu.Lock()
+	a.store.setSession(session)
+	a.store.mu.Unlock()
+
+	return a.signSession(session, role)
+}
+
+func (a MemoryAuthRepo) GetTokenClaims(ctx context.Context, tokenStr string, out any) error {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.GetTokenClaims")
+	defer span.End()
+
+	return a.decodeClaims(tokenStr, out, func(claims model.TokenClaims) error {
+		a.store.mu.Lock()
+		defer a.store.mu.Unlock()
+
+		value, ok := a.store.get(revokedKey(claims.UserID))
+		if !ok {
+			return nil
+		}
+
+		revokedAt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		if claims.IssuedAt <= revokedAt {
+			return ErrTokenRevoked
+		}
+		return nil
+	})
+}
+
+func (a MemoryAuthRepo) RevokeTokens(ctx context.Context, uid int) error {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.RevokeTokens")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.set(revokedKey(uid), strconv.FormatInt(time.Now().UnixMilli(), 10), tokenTTL)
+
+	for id, session := range a.store.sessions {
+		if session.UserID == uid {
+			delete(a.store.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (a MemoryAuthRepo) GenerateVerificationToken(ctx context.Context, uid int, email string, ttl time.Duration) (string, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.GenerateVerificationToken")
+	defer span.End()
+
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := a.signToken(model.VerificationClaims{
+		ID:      id,
+		UserID:  uid,
+		Email:   email,
+		Purpose: verifyEmailPurpose,
+		Expires: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.set(verificationKey(id), strconv.Itoa(uid), ttl)
+	return signed, nil
+}
+
+func (a MemoryAuthRepo) ConsumeVerificationToken(ctx context.Context, token string) (model.VerificationClaims, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.ConsumeVerificationToken")
+	defer span.End()
+
+	out, ok := a.parseVerification(token, verifyEmailPurpose)
+	if !ok {
+		return out, ErrInvalidVerificationToken
+	}
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	if a.store.del(verificationKey(out.ID)) == 0 {
+		return out, ErrInvalidVerificationToken
+	}
+	return out, nil
+}
+
+func (a MemoryAuthRepo) GenerateMagicLinkToken(ctx context.Context, uid int, email, fingerprint string, ttl time.Duration) (string, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.GenerateMagicLinkToken")
+	defer span.End()
+
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := a.signToken(model.VerificationClaims{
+		ID:      id,
+		UserID:  uid,
+		Email:   email,
+		Purpose: magicLinkPurpose,
+		Expires: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.set(oneTimeKey(magicLinkPurpose, signed), fingerprint, ttl)
+	return signed, nil
+}
+
+func (a MemoryAuthRepo) ConsumeMagicLinkToken(ctx context.Context, token, fingerprint string) (model.VerificationClaims, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.ConsumeMagicLinkToken")
+	defer span.End()
+
+	out, ok := a.parseVerification(token, magicLinkPurpose)
+	if !ok {
+		return out, ErrInvalidMagicLinkToken
+	}
+
+	key := oneTimeKey(magicLinkPurpose, token)
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	bound, ok := a.store.get(key)
+	if !ok || subtle.ConstantTimeCompare([]byte(bound), []byte(fingerprint)) != 1 {
+		return out, ErrInvalidMagicLinkToken
+	}
+
+	a.store.del(key)
+	return out, nil
+}
+
+func (a MemoryAuthRepo) IssueOneTimeToken(ctx context.Context, purpose, value string, ttl time.Duration) (string, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.IssueOneTimeToken")
+	defer span.End()
+
+	if ttl <= 0 {
+		return "", errOneTimeTokenTTL
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.set(oneTimeKey(purpose, token), value, ttl)
+	return token, nil
+}
+
+func (a MemoryAuthRepo) PeekOneTimeToken(ctx context.Context, purpose, token string) (string, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.PeekOneTimeToken")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	value, ok := a.store.get(oneTimeKey(purpose, token))
+	if !ok {
+		return "", ErrInvalidOneTimeToken
+	}
+	return value, nil
+}
+
+func (a MemoryAuthRepo) ConsumeOneTimeToken(ctx context.Context, purpose, token string) (string, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.ConsumeOneTimeToken")
+	defer span.End()
+
+	key := oneTimeKey(purpose, token)
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	value, ok := a.store.get(key)
+	if !ok {
+		return "", ErrInvalidOneTimeToken
+	}
+
+	a.store.del(key)
+	return value, nil
+}
+
+func (a MemoryAuthRepo) ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.ClaimOnce")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	return a.store.setNX(claimedKey(key), "1", ttl), nil
+}
+
+func (a MemoryAuthRepo) IssueOTP(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.IssueOTP")
+	defer span.End()
+
+	code, err := randomCode()
+	if err != nil {
+		return "", err
+	}
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.set(otpKey(key), a.otpHash(key, code), ttl)
+	return code, nil
+}
+
+func (a MemoryAuthRepo) ConsumeOTP(ctx context.Context, key, code string) error {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.ConsumeOTP")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	stored, ok := a.store.get(otpKey(key))
+	if !ok || !hmac.Equal([]byte(stored), []byte(a.otpHash(key, code))) {
+		return ErrInvalidOTP
+	}
+
+	a.store.del(otpKey(key))
+	return nil
+}
+
+func (a MemoryAuthRepo) DiscardOTP(ctx context.Context, key string) error {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.DiscardOTP")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.del(otpKey(key))
+	return nil
+}
+
+// GetSessions returns the live sessions of uid, most recently used first.
+func (a MemoryAuthRepo) GetSessions(ctx context.Context, uid int) ([]model.Session, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.GetSessions")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	sessions := []model.Session{}
+	for id := range a.store.sessions {
+		session, ok := a.store.session(id)
+		if ok && session.UserID == uid {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (a MemoryAuthRepo) TouchSession(ctx context.Context, id string, expires int64) error {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.TouchSession")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	session, ok := a.store.session(id)
+	if !ok {
+		return ErrSessionNotFound
+	}
+
+	session.LastSeenAt = time.Now()
+	a.store.setSession(session)
+	return nil
+}
+
+func (a MemoryAuthRepo) DeleteSession(ctx context.Context, uid int, id string) error {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.DeleteSession")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	// other users' sessions are reported as missing rather than forbidden
+	session, ok := a.store.session(id)
+	if !ok || session.UserID != uid {
+		return ErrSessionNotFound
+	}
+
+	delete(a.store.sessions, id)
+	return nil
+}
+
+func (a MemoryAuthRepo) CountAttempt(ctx context.Context, key string, window time.Duration) (int64, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.CountAttempt")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	return a.store.incr(attemptKey(key), window), nil
+}
+
+func (a MemoryAuthRepo) GetAttempts(ctx context.Context, key string) (int64, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.GetAttempts")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	value, ok := a.store.get(attemptKey(key))
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func (a MemoryAuthRepo) ResetAttempts(ctx context.Context, key string) error {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.ResetAttempts")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.del(attemptKey(key), blockKey(key))
+	return nil
+}
+
+func (a MemoryAuthRepo) Block(ctx context.Context, key string, ttl time.Duration) error {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.Block")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	a.store.set(blockKey(key), "1", ttl)
+	return nil
+}
+
+func (a MemoryAuthRepo) BlockedFor(ctx context.Context, key string) (time.Duration, error) {
+	_, span := tracing.Start(ctx, "MemoryAuthRepo.BlockedFor")
+	defer span.End()
+
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+
+	return a.store.ttl(blockKey(key)), nil
+}
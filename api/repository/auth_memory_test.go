@@ -0,0 +1,220 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/repository/model"
+)
+
+func TestMemoryAuthRepoOneTimeToken(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		use  func(r repository.MemoryAuthRepo, token string) error
+		err  error
+	}{
+		{
+			name: "consumed",
+			ttl:  time.Minute,
+			use: func(r repository.MemoryAuthRepo, token string) error {
+				_, err := r.ConsumeOneTimeToken(ctx, "purpose", token)
+				return err
+			},
+		},
+		{
+			name: "consumed twice",
+			ttl:  time.Minute,
+			use: func(r repository.MemoryAuthRepo, token string) error {
+				if _, err := r.ConsumeOneTimeToken(ctx, "purpose", token); err != nil {
+					return err
+				}
+				_, err := r.ConsumeOneTimeToken(ctx, "purpose", token)
+				return err
+			},
+			err: repository.ErrInvalidOneTimeToken,
+		},
+		{
+			name: "peeked before being consumed",
+			ttl:  time.Minute,
+			use: func(r repository.MemoryAuthRepo, token string) error {
+				if _, err := r.PeekOneTimeToken(ctx, "purpose", token); err != nil {
+					return err
+				}
+				_, err := r.ConsumeOneTimeToken(ctx, "purpose", token)
+				return err
+			},
+		},
+		{
+			name: "consumed for another purpose",
+			ttl:  time.Minute,
+			use: func(r repository.MemoryAuthRepo, token string) error {
+				_, err := r.ConsumeOneTimeToken(ctx, "other", token)
+				return err
+			},
+			err: repository.ErrInvalidOneTimeToken,
+		},
+		{
+			name: "expired",
+			ttl:  time.Millisecond,
+			use: func(r repository.MemoryAuthRepo, token string) error {
+				time.Sleep(5 * time.Millisecond)
+				_, err := r.PeekOneTimeToken(ctx, "purpose", token)
+				return err
+			},
+			err: repository.ErrInvalidOneTimeToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := repository.NewMemoryAuthRepo(discard(), nil, "secret")
+
+			token, err := r.IssueOneTimeToken(ctx, "purpose", "value", tt.ttl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tt.use(r, token); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	t.Run("without ttl", func(t *testing.T) {
+		r := repository.NewMemoryAuthRepo(discard(), nil, "secret")
+
+		for _, ttl := range []time.Duration{0, -time.Second} {
+			if _, err := r.IssueOneTimeToken(ctx, "purpose", "value", ttl); err == nil {
+				t.Fatalf("ttl %s: got a token, want an error", ttl)
+			}
+		}
+	})
+}
+
+func TestMemoryAuthRepoConcurrentConsume(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewMemoryAuthRepo(discard(), nil, "secret")
+
+	token, err := r.IssueOneTimeToken(ctx, "purpose", "value", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var consumed atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			value, err := r.ConsumeOneTimeToken(ctx, "purpose", token)
+			switch {
+			case err == nil && value == "value":
+				consumed.Add(1)
+			case !errors.Is(err, repository.ErrInvalidOneTimeToken):
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := consumed.Load(); got != 1 {
+		t.Fatalf("token consumed %d times, want once", got)
+	}
+}
+
+func TestMemoryAuthRepoClaimOnce(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewMemoryAuthRepo(discard(), nil, "secret")
+
+	for _, want := range []bool{true, false} {
+		if got, _ := r.ClaimOnce(ctx, "key", 5*time.Millisecond); got != want {
+			t.Fatalf("got %t, want %t", got, want)
+		}
+	}
+
+	// the claim is released once it expires
+	time.Sleep(10 * time.Millisecond)
+	if got, _ := r.ClaimOnce(ctx, "key", time.Minute); !got {
+		t.Fatal("expired claim not released")
+	}
+}
+
+func TestMemoryAuthRepoCountAttempt(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewMemoryAuthRepo(discard(), nil, "secret")
+
+	for want := int64(1); want <= 3; want++ {
+		if got, _ := r.CountAttempt(ctx, "key", 5*time.Millisecond); got != want {
+			t.Fatalf("got %d attempts, want %d", got, want)
+		}
+	}
+
+	// the count restarts once the window is over
+	time.Sleep(10 * time.Millisecond)
+	if got, _ := r.CountAttempt(ctx, "key", time.Minute); got != 1 {
+		t.Fatalf("got %d attempts after the window, want 1", got)
+	}
+}
+
+func TestMemoryAuthRepoRevokeTokens(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewMemoryAuthRepo(discard(), nil, "secret")
+
+	generate := func(uid int) model.Token {
+		t.Helper()
+
+		token, err := r.GenerateToken(ctx, uid, "user", model.Session{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	first, second, other := generate(1), generate(1), generate(2)
+
+	// revocations cover the tokens issued up to the same millisecond
+	time.Sleep(2 * time.Millisecond)
+	if err := r.RevokeTokens(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	after := generate(1)
+
+	tests := []struct {
+		name  string
+		token model.Token
+		err   error
+	}{
+		{name: "first token", token: first, err: repository.ErrTokenRevoked},
+		{name: "second token", token: second, err: repository.ErrTokenRevoked},
+		{name: "token of another user", token: other},
+		{name: "token issued after the revocation", token: after},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims model.TokenClaims
+			if err := r.GetTokenClaims(ctx, tt.token.Token, &claims); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	// the sessions of the revoked tokens are gone
+	sessions, err := r.GetSessions(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want the one issued after the revocation", len(sessions))
+	}
+}
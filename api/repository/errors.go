@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/muzz/api/pkg/errs"
+)
+
+// postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	uniqueViolation       = "23505"
+	foreignKeyViolation   = "23503"
+	notNullViolation      = "23502"
+	checkViolation        = "23514"
+	stringDataTruncation  = "22001"
+	invalidDatetimeFormat = "22007"
+	datetimeFieldOverflow = "22008"
+	invalidTextValue      = "22P02"
+)
+
+var (
+	ErrEmailTaken        = errs.New(errs.Conflict, "email_taken", "email already in use")
+	ErrPhoneTaken        = errs.New(errs.Conflict, "phone_taken", "phone number already in use")
+	ErrAlreadyExists     = errs.New(errs.Conflict, "already_exists", "resource already exists")
+	ErrReferenceNotFound = errs.New(errs.NotFound, "reference_not_found", "referenced resource not found")
+	ErrInvalidData       = errs.New(errs.Validation, "invalid_data", "invalid data")
+)
+
+// constraintErrors holds the errors reported for violations of constraints
+// that clients can act upon, other violations get a generic error of their
+// kind.
+var constraintErrors = map[string]*errs.Error{
+	"users_email_key":                 ErrEmailTaken,
+	"users_phone_key":                 ErrPhoneTaken,
+	"user_swipes_user_id_fkey":        ErrUserNotFound,
+	"user_swipes_swiped_user_id_fkey": ErrUserNotFound,
+	"matches_user1_id_fkey":           ErrUserNotFound,
+	"matches_user2_id_fkey":           ErrUserNotFound,
+}
+
+// translate turns postgres errors into domain errors, the original error is
+// kept as their cause. Other errors are returned untouched.
+func translate(err error) error {
+	code, constraint, ok := postgresError(err)
+	if !ok {
+		return err
+	}
+
+	if e, ok := constraintErrors[constraint]; ok {
+		return e.Wrap(err)
+	}
+
+	switch code {
+	case uniqueViolation:
+		return ErrAlreadyExists.Wrap(err)
+	case foreignKeyViolation:
+		return ErrReferenceNotFound.Wrap(err)
+	case notNullViolation, checkViolation, stringDataTruncation,
+		invalidDatetimeFormat, datetimeFieldOverflow, invalidTextValue:
+		return ErrInvalidData.Wrap(err)
+	}
+	return err
+}
+
+// postgresError extracts the error code and constraint name of errors raised
+// through pgx (the application driver) or lib/pq (used by the migrations).
+func postgresError(err error) (code, constraint string, ok bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code, pgErr.ConstraintName, true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code), pqErr.Constraint, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+)
+
+const magicLinkPurpose = "magic_link"
+
+var (
+	ErrInvalidMagicLinkToken = errs.New(errs.Unauthorized, "invalid_magic_link", "invalid or expired magic link")
+)
+
+// GenerateMagicLinkToken signs a login token for uid that can only be
+// redeemed once, within ttl and from the device matching fingerprint. Only a
+// hash of the token is kept in the cache.
+func (a AuthRepo) GenerateMagicLinkToken(ctx context.Context, uid int, email, fingerprint string, ttl time.Duration) (string, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.GenerateMagicLinkToken")
+	defer span.End()
+
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := a.signToken(model.VerificationClaims{
+		ID:      id,
+		UserID:  uid,
+		Email:   email,
+		Purpose: magicLinkPurpose,
+		Expires: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.cache.WithContext(ctx).Set(oneTimeKey(magicLinkPurpose, signed), fingerprint, ttl).Err(); err != nil {
+		return "", err
+	}
+
+	return signed, nil
+}
+
+func (a AuthRepo) ConsumeMagicLinkToken(ctx context.Context, token, fingerprint string) (model.VerificationClaims, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.ConsumeMagicLinkToken")
+	defer span.End()
+
+	out, ok := a.parseVerification(token, magicLinkPurpose)
+	if !ok {
+		return out, ErrInvalidMagicLinkToken
+	}
+
+	key := oneTimeKey(magicLinkPurpose, token)
+
+	bound, err := a.cache.WithContext(ctx).Get(key).Result()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return out, ErrInvalidMagicLinkToken
+		}
+		return out, err
+	}
+
+	// a link opened on another device is rejected but left intact, so that
+	// an intercepted email cannot be used to burn the owner's link
+	if subtle.ConstantTimeCompare([]byte(bound), []byte(fingerprint)) != 1 {
+		return out, ErrInvalidMagicLinkToken
+	}
+
+	deleted, err := a.cache.WithContext(ctx).Del(key).Result()
+	if err != nil {
+		return out, err
+	}
+
+	if deleted == 0 {
+		return out, ErrInvalidMagicLinkToken
+	}
+
+	return out, nil
+}
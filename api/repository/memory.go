@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/muzz/api/repository/model"
+)
+
+// expired entries are swept every sweepEvery writes, besides being dropped
+// when read
+const sweepEvery = 1024
+
+// memoryStore holds expiring values and sessions in place of redis. Its
+// methods expect mu to be held, so that callers can combine them atomically.
+type memoryStore struct {
+	mu       sync.Mutex
+	values   map[string]memoryValue
+	sessions map[string]model.Session
+	writes   int
+}
+
+// memoryValue expires at expires, every value stored having an expiry so
+// that the store cannot grow without bounds.
+type memoryValue struct {
+	value   string
+	expires time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		values:   map[string]memoryValue{},
+		sessions: map[string]model.Session{},
+	}
+}
+
+func (v memoryValue) expired(now time.Time) bool {
+	return !now.Before(v.expires)
+}
+
+func (s *memoryStore) get(key string) (string, bool) {
+	v, ok := s.values[key]
+	if !ok {
+		return "", false
+	}
+
+	if v.expired(time.Now()) {
+		delete(s.values, key)
+		return "", false
+	}
+	return v.value, true
+}
+
+// set stores value under key for ttl, a key set without a positive ttl
+// being already expired.
+func (s *memoryStore) set(key, value string, ttl time.Duration) {
+	if ttl <= 0 {
+		delete(s.values, key)
+		return
+	}
+
+	s.values[key] = memoryValue{value: value, expires: time.Now().Add(ttl)}
+	s.written()
+}
+
+// setNX stores value under key unless it is already set, reporting whether
+// it was stored.
+func (s *memoryStore) setNX(key, value string, ttl time.Duration) bool {
+	if _, ok := s.get(key); ok {
+		return false
+	}
+
+	s.set(key, value, ttl)
+	return true
+}
+
+// incr increments the counter under key, a new counter expiring after ttl
+// and an existing one keeping its expiry.
+func (s *memoryStore) incr(key string, ttl time.Duration) int64 {
+	value, ok := s.get(key)
+	if !ok {
+		s.set(key, "1", ttl)
+		return 1
+	}
+
+	count, _ := strconv.ParseInt(value, 10, 64)
+	count++
+
+	v := s.values[key]
+	v.value = strconv.FormatInt(count, 10)
+	s.values[key] = v
+	s.written()
+
+	return count
+}
+
+// ttl returns how long key has left, zero when it is missing.
+func (s *memoryStore) ttl(key string) time.Duration {
+	if _, ok := s.get(key); !ok {
+		return 0
+	}
+	return time.Until(s.values[key].expires)
+}
+
+// del deletes keys and returns how many of them were set.
+func (s *memoryStore) del(keys ...string) int {
+	deleted := 0
+	for _, key := range keys {
+		if _, ok := s.get(key); ok {
+			deleted++
+		}
+		delete(s.values, key)
+	}
+	return deleted
+}
+
+func (s *memoryStore) session(id string) (model.Session, bool) {
+	session, ok := s.sessions[id]
+	if !ok {
+		return model.Session{}, false
+	}
+
+	if !time.Now().Before(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return model.Session{}, false
+	}
+	return session, true
+}
+
+func (s *memoryStore) setSession(session model.Session) {
+	s.sessions[session.ID] = session
+	s.written()
+}
+
+func (s *memoryStore) written() {
+	s.writes++
+	if s.writes%sweepEvery == 0 {
+		s.sweep()
+	}
+}
+
+func (s *memoryStore) sweep() {
+	now := time.Now()
+
+	for key, v := range s.values {
+		if v.expired(now) {
+			delete(s.values, key)
+		}
+	}
+
+	for id, session := range s.sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+type AuditEventInput struct {
+	Type      string         `db:"event_type"`
+	ActorID   *int           `db:"actor_id"`
+	UserID    *int           `db:"user_id"`
+	IP        *string        `db:"ip"`
+	UserAgent *string        `db:"user_agent"`
+	Details   types.JSONText `db:"details"`
+}
+
+type AuditEvent struct {
+	ID        int64          `db:"id"`
+	Type      string         `db:"event_type"`
+	ActorID   *int           `db:"actor_id"`
+	UserID    *int           `db:"user_id"`
+	IP        *string        `db:"ip"`
+	UserAgent *string        `db:"user_agent"`
+	Details   types.JSONText `db:"details"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+type AuditFilter struct {
+	UserID *int
+	From   *time.Time
+	To     *time.Time
+	Limit  uint64
+}
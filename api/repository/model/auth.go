@@ -9,8 +9,19 @@ type Token struct {
 }
 
 type TokenClaims struct {
-	UserID     int   `json:"user_id"`
-	Authorized bool  `json:"authorized"`
-	Expires    int64 `json:"expires"`
+	UserID     int    `json:"user_id"`
+	SessionID  string `json:"session_id"`
+	Authorized bool   `json:"authorized"`
+	Role       string `json:"role"`
+	IssuedAt   int64  `json:"issued_at"`
+	Expires    int64  `json:"expires"`
 	jwt.StandardClaims
 }
+
+type VerificationClaims struct {
+	ID      string `json:"jti"`
+	UserID  int    `json:"user_id"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose"`
+	Expires int64  `json:"expires"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type Passkey struct {
+	ID              []byte     `db:"id"`
+	UserID          int        `db:"user_id"`
+	PublicKey       []byte     `db:"public_key"`
+	AttestationType string     `db:"attestation_type"`
+	AAGUID          []byte     `db:"aaguid"`
+	Transports      string     `db:"transports"`
+	UserVerified    bool       `db:"user_verified"`
+	BackupEligible  bool       `db:"backup_eligible"`
+	BackupState     bool       `db:"backup_state"`
+	SignCount       int64      `db:"sign_count"`
+	CloneWarning    bool       `db:"clone_warning"`
+	CreatedAt       time.Time  `db:"created_at"`
+	LastUsedAt      *time.Time `db:"last_used_at"`
+}
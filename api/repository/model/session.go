@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"user_id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"-"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
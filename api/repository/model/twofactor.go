@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type TwoFactor struct {
+	UserID      int        `db:"user_id"`
+	Secret      string     `db:"secret"`
+	Enabled     bool       `db:"enabled"`
+	CreatedAt   time.Time  `db:"created_at"`
+	ConfirmedAt *time.Time `db:"confirmed_at"`
+}
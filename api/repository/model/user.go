@@ -3,24 +3,30 @@ package model
 import "time"
 
 type UserInput struct {
-	Email        string   `db:"email"`
+	Email        *string  `db:"email"`
+	Phone        *string  `db:"phone"`
 	Password     string   `db:"password"`
 	Name         string   `db:"name"`
 	Gender       string   `db:"gender"`
 	DOB          string   `db:"date_of_birth"`
 	LocationLat  *float64 `db:"location_lat"`
 	LocationLong *float64 `db:"location_long"`
+	Role         string   `db:"role"`
 }
 
 type User struct {
-	ID           int64     `db:"id"`
-	Email        string    `db:"email"`
-	Password     string    `db:"password"`
-	Name         string    `db:"name"`
-	Gender       string    `db:"gender"`
-	DOB          time.Time `db:"date_of_birth"`
-	LocationLat  *float64  `db:"location_lat"`
-	LocationLong *float64  `db:"location_long"`
+	ID            int64     `db:"id"`
+	Email         *string   `db:"email"`
+	Phone         *string   `db:"phone"`
+	Password      string    `db:"password"`
+	Name          string    `db:"name"`
+	Gender        string    `db:"gender"`
+	DOB           time.Time `db:"date_of_birth"`
+	LocationLat   *float64  `db:"location_lat"`
+	LocationLong  *float64  `db:"location_long"`
+	Role          string    `db:"role"`
+	EmailVerified bool      `db:"email_verified"`
+	PhoneVerified bool      `db:"phone_verified"`
 }
 
 type Swipe struct {
@@ -36,6 +42,7 @@ type Match struct {
 	User1ID   int `db:"user1_id"`
 	User2ID   int `db:"user2_id"`
 	IsMatch   bool
+	Created   bool
 	CreatedAt time.Time `db:"created_at"`
 }
 
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/tracing"
+)
+
+var (
+	ErrInvalidOneTimeToken = errs.New(errs.Unauthorized, "invalid_token", "invalid or expired token")
+
+	// a token issued without a ttl would never expire
+	errOneTimeTokenTTL = errors.New("one-time token ttl must be positive")
+)
+
+// IssueOneTimeToken returns a random opaque token bound to value for ttl.
+// Only a hash of the token is kept in the cache, so a cache leak does not
+// leak usable tokens.
+func (a AuthRepo) IssueOneTimeToken(ctx context.Context, purpose, value string, ttl time.Duration) (string, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.IssueOneTimeToken")
+	defer span.End()
+
+	if ttl <= 0 {
+		return "", errOneTimeTokenTTL
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.cache.WithContext(ctx).Set(oneTimeKey(purpose, token), value, ttl).Err(); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// PeekOneTimeToken returns the value bound to token without invalidating it.
+func (a AuthRepo) PeekOneTimeToken(ctx context.Context, purpose, token string) (string, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.PeekOneTimeToken")
+	defer span.End()
+
+	value, err := a.cache.WithContext(ctx).Get(oneTimeKey(purpose, token)).Result()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return "", ErrInvalidOneTimeToken
+		}
+		return "", err
+	}
+	return value, nil
+}
+
+// ConsumeOneTimeToken returns the value bound to token and invalidates it.
+func (a AuthRepo) ConsumeOneTimeToken(ctx context.Context, purpose, token string) (string, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.ConsumeOneTimeToken")
+	defer span.End()
+
+	key := oneTimeKey(purpose, token)
+
+	value, err := a.cache.WithContext(ctx).Get(key).Result()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return "", ErrInvalidOneTimeToken
+		}
+		return "", err
+	}
+
+	// only the caller that actually removes the key may use the token
+	deleted, err := a.cache.WithContext(ctx).Del(key).Result()
+	if err != nil {
+		return "", err
+	}
+
+	if deleted == 0 {
+		return "", ErrInvalidOneTimeToken
+	}
+
+	return value, nil
+}
+
+// ClaimOnce reports whether key was claimed for the first time within ttl,
+// which lets callers refuse replays of otherwise valid codes.
+func (a AuthRepo) ClaimOnce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.ClaimOnce")
+	defer span.End()
+
+	return a.cache.WithContext(ctx).SetNX(claimedKey(key), 1, ttl).Result()
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func claimedKey(key string) string {
+	return fmt.Sprintf("claimed:%s", key)
+}
+
+func oneTimeKey(purpose, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("%s:%s", purpose, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/pg"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrPasskeyNotFound      = errs.New(errs.NotFound, "passkey_not_found", "passkey not found")
+	ErrPasskeyAlreadyExists = errs.New(errs.Conflict, "passkey_exists", "passkey already registered")
+)
+
+type PasskeyConnector interface {
+	GetPasskeys(ctx context.Context, userID int) ([]model.Passkey, error)
+	GetPasskey(ctx context.Context, id []byte) (model.Passkey, error)
+	CreatePasskey(ctx context.Context, passkey model.Passkey) error
+	UpdatePasskeyUsage(ctx context.Context, id []byte, signCount int64, cloneWarning bool) error
+}
+
+type PasskeyRepo struct {
+	l  *logrus.Logger
+	db *pg.Postgres
+}
+
+func NewPasskeyRepo(l *logrus.Logger, db *pg.Postgres) PasskeyRepo {
+	return PasskeyRepo{
+		l:  l,
+		db: db,
+	}
+}
+
+func (r PasskeyRepo) GetPasskeys(ctx context.Context, userID int) ([]model.Passkey, error) {
+	ctx, span := tracing.Start(ctx, "PasskeyRepo.GetPasskeys")
+	defer span.End()
+
+	out := []model.Passkey{}
+
+	query := `SELECT * FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at`
+	if err := r.db.DBX().SelectContext(ctx, &out, query, userID); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r PasskeyRepo) GetPasskey(ctx context.Context, id []byte) (model.Passkey, error) {
+	ctx, span := tracing.Start(ctx, "PasskeyRepo.GetPasskey")
+	defer span.End()
+
+	var out model.Passkey
+
+	query := `SELECT * FROM webauthn_credentials WHERE id = $1`
+	if err := r.db.DBX().GetContext(ctx, &out, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Passkey{}, ErrPasskeyNotFound
+		}
+		return model.Passkey{}, err
+	}
+	return out, nil
+}
+
+func (r PasskeyRepo) CreatePasskey(ctx context.Context, passkey model.Passkey) error {
+	ctx, span := tracing.Start(ctx, "PasskeyRepo.CreatePasskey")
+	defer span.End()
+
+	query := `INSERT INTO webauthn_credentials (id, user_id, public_key, attestation_type, aaguid, transports,
+                                                user_verified, backup_eligible, backup_state, sign_count)
+              VALUES (:id, :user_id, :public_key, :attestation_type, :aaguid, :transports,
+                      :user_verified, :backup_eligible, :backup_state, :sign_count)
+              ON CONFLICT (id) DO NOTHING`
+
+	res, err := r.db.DBX().NamedExecContext(ctx, query, passkey)
+	if err != nil {
+		return translate(err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrPasskeyAlreadyExists
+	}
+	return nil
+}
+
+// UpdatePasskeyUsage records the signature counter reported by the
+// authenticator on its latest assertion.
+func (r PasskeyRepo) UpdatePasskeyUsage(ctx context.Context, id []byte, signCount int64, cloneWarning bool) error {
+	ctx, span := tracing.Start(ctx, "PasskeyRepo.UpdatePasskeyUsage")
+	defer span.End()
+
+	_, err := r.db.DBX().ExecContext(ctx, `UPDATE webauthn_credentials
+                                           SET sign_count = $2, clone_warning = clone_warning OR $3, last_used_at = CURRENT_TIMESTAMP
+                                           WHERE id = $1`, id, signCount, cloneWarning)
+	return err
+}
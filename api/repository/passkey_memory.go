@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryPasskeyRepo is a PasskeyConnector keeping the credentials in memory
+// rather than in postgres.
+type MemoryPasskeyRepo struct {
+	l        *logrus.Logger
+	passkeys *passkeyTable
+}
+
+type passkeyTable struct {
+	mu sync.RWMutex
+	// keyed by credential id
+	rows map[string]model.Passkey
+}
+
+func NewMemoryPasskeyRepo(l *logrus.Logger) MemoryPasskeyRepo {
+	return MemoryPasskeyRepo{
+		l:        l,
+		passkeys: &passkeyTable{rows: map[string]model.Passkey{}},
+	}
+}
+
+func (r MemoryPasskeyRepo) GetPasskeys(ctx context.Context, userID int) ([]model.Passkey, error) {
+	_, span := tracing.Start(ctx, "MemoryPasskeyRepo.GetPasskeys")
+	defer span.End()
+
+	r.passkeys.mu.RLock()
+	defer r.passkeys.mu.RUnlock()
+
+	out := []model.Passkey{}
+	for _, passkey := range r.passkeys.rows {
+		if passkey.UserID == userID {
+			out = append(out, passkey)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+func (r MemoryPasskeyRepo) GetPasskey(ctx context.Context, id []byte) (model.Passkey, error) {
+	_, span := tracing.Start(ctx, "MemoryPasskeyRepo.GetPasskey")
+	defer span.End()
+
+	r.passkeys.mu.RLock()
+	defer r.passkeys.mu.RUnlock()
+
+	passkey, ok := r.passkeys.rows[string(id)]
+	if !ok {
+		return model.Passkey{}, ErrPasskeyNotFound
+	}
+	return passkey, nil
+}
+
+func (r MemoryPasskeyRepo) CreatePasskey(ctx context.Context, passkey model.Passkey) error {
+	_, span := tracing.Start(ctx, "MemoryPasskeyRepo.CreatePasskey")
+	defer span.End()
+
+	r.passkeys.mu.Lock()
+	defer r.passkeys.mu.Unlock()
+
+	if _, ok := r.passkeys.rows[string(passkey.ID)]; ok {
+		return ErrPasskeyAlreadyExists
+	}
+
+	passkey.CloneWarning = false
+	passkey.CreatedAt = time.Now()
+	passkey.LastUsedAt = nil
+
+	r.passkeys.rows[string(passkey.ID)] = passkey
+	return nil
+}
+
+// UpdatePasskeyUsage records the signature counter reported by the
+// authenticator on its latest assertion.
+func (r MemoryPasskeyRepo) UpdatePasskeyUsage(ctx context.Context, id []byte, signCount int64, cloneWarning bool) error {
+	_, span := tracing.Start(ctx, "MemoryPasskeyRepo.UpdatePasskeyUsage")
+	defer span.End()
+
+	r.passkeys.mu.Lock()
+	defer r.passkeys.mu.Unlock()
+
+	passkey, ok := r.passkeys.rows[string(id)]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	passkey.SignCount = signCount
+	passkey.CloneWarning = passkey.CloneWarning || cloneWarning
+	passkey.LastUsedAt = &now
+
+	r.passkeys.rows[string(id)] = passkey
+	return nil
+}
@@ -0,0 +1,13 @@
+package repository
+
+const (
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+// Settings selects where the repositories keep their data. The postgres
+// driver stores users in postgres and tokens in redis, the memory driver
+// keeps everything in the memory of the process.
+type Settings struct {
+	Driver string `env:"REPOSITORY_DRIVER" envDefault:"postgres"`
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+)
+
+var (
+	ErrSessionNotFound = errs.New(errs.NotFound, "session_not_found", "session not found")
+)
+
+// createSession stores session until it expires. The last seen time lives in
+// its own key so that touching a session never rewrites the record itself.
+func (a AuthRepo) createSession(ctx context.Context, session model.Session) (model.Session, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	session.ID = id
+	session.LastSeenAt = session.CreatedAt
+
+	b, err := json.Marshal(session)
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	ttl := time.Until(session.ExpiresAt)
+
+	_, err = a.cache.WithContext(ctx).TxPipelined(func(pipe goredis.Pipeliner) error {
+		pipe.Set(sessionKey(id), b, ttl)
+		pipe.Set(lastSeenKey(id), session.LastSeenAt.UnixMilli(), ttl)
+		pipe.SAdd(userSessionsKey(session.UserID), id)
+		// sessions never outlive tokenTTL, neither does the index
+		pipe.Expire(userSessionsKey(session.UserID), tokenTTL)
+		return nil
+	})
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	return session, nil
+}
+
+// GetSessions returns the live sessions of uid, most recently used first.
+func (a AuthRepo) GetSessions(ctx context.Context, uid int) ([]model.Session, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.GetSessions")
+	defer span.End()
+
+	ids, err := a.cache.WithContext(ctx).SMembers(userSessionsKey(uid)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []model.Session{}
+	for _, id := range ids {
+		values, err := a.cache.WithContext(ctx).MGet(sessionKey(id), lastSeenKey(id)).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		record, ok := values[0].(string)
+		if !ok {
+			// expired, drop it from the index
+			if err := a.cache.WithContext(ctx).SRem(userSessionsKey(uid), id).Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var session model.Session
+		if err := json.Unmarshal([]byte(record), &session); err != nil {
+			return nil, err
+		}
+
+		session.LastSeenAt = session.CreatedAt
+		if seen, ok := values[1].(string); ok {
+			if ms, err := strconv.ParseInt(seen, 10, 64); err == nil {
+				session.LastSeenAt = time.UnixMilli(ms)
+			}
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// TouchSession records activity on session id, failing with
+// ErrSessionNotFound once the session has been deleted.
+func (a AuthRepo) TouchSession(ctx context.Context, id string, expires int64) error {
+	ctx, span := tracing.Start(ctx, "AuthRepo.TouchSession")
+	defer span.End()
+
+	exists, err := a.cache.WithContext(ctx).Exists(sessionKey(id)).Result()
+	if err != nil {
+		return err
+	}
+
+	if exists == 0 {
+		return ErrSessionNotFound
+	}
+
+	return a.cache.WithContext(ctx).Set(lastSeenKey(id), time.Now().UnixMilli(), time.Until(time.Unix(expires, 0))).Err()
+}
+
+// DeleteSession ends session id of uid, the token it was issued with stops
+// working right away.
+func (a AuthRepo) DeleteSession(ctx context.Context, uid int, id string) error {
+	ctx, span := tracing.Start(ctx, "AuthRepo.DeleteSession")
+	defer span.End()
+
+	record, err := a.cache.WithContext(ctx).Get(sessionKey(id)).Result()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	var session model.Session
+	if err := json.Unmarshal([]byte(record), &session); err != nil {
+		return err
+	}
+
+	// other users' sessions are reported as missing rather than forbidden
+	if session.UserID != uid {
+		return ErrSessionNotFound
+	}
+
+	_, err = a.cache.WithContext(ctx).TxPipelined(func(pipe goredis.Pipeliner) error {
+		pipe.Del(sessionKey(id), lastSeenKey(id))
+		pipe.SRem(userSessionsKey(uid), id)
+		return nil
+	})
+	return err
+}
+
+func (a AuthRepo) deleteSessions(ctx context.Context, uid int) error {
+	ids, err := a.cache.WithContext(ctx).SMembers(userSessionsKey(uid)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userSessionsKey(uid)}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id), lastSeenKey(id))
+	}
+
+	return a.cache.WithContext(ctx).Del(keys...).Err()
+}
+
+func sessionKey(id string) string {
+	return fmt.Sprintf("session:%s", id)
+}
+
+func lastSeenKey(id string) string {
+	return fmt.Sprintf("session_seen:%s", id)
+}
+
+func userSessionsKey(uid int) string {
+	return fmt.Sprintf("sessions:%d", uid)
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/pg"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrTwoFactorNotFound   = errs.New(errs.NotFound, "two_factor_not_found", "two factor authentication not set up")
+	ErrInvalidRecoveryCode = errs.New(errs.Unauthorized, "invalid_recovery_code", "invalid recovery code")
+)
+
+type TwoFactorConnector interface {
+	GetTwoFactor(ctx context.Context, userID int) (model.TwoFactor, error)
+	SaveTwoFactorSecret(ctx context.Context, userID int, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	DisableTwoFactor(ctx context.Context, userID int) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+}
+
+// TwoFactorRepo stores totp secrets encrypted with a key derived from the
+// application secret, a database dump alone is not enough to generate codes.
+type TwoFactorRepo struct {
+	l   *logrus.Logger
+	db  *pg.Postgres
+	key []byte
+}
+
+func NewTwoFactorRepo(l *logrus.Logger, db *pg.Postgres, secret string) TwoFactorRepo {
+	key := sha256.Sum256([]byte(secret))
+
+	return TwoFactorRepo{
+		l:   l,
+		db:  db,
+		key: key[:],
+	}
+}
+
+func (r TwoFactorRepo) GetTwoFactor(ctx context.Context, userID int) (model.TwoFactor, error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorRepo.GetTwoFactor")
+	defer span.End()
+
+	var out model.TwoFactor
+
+	query := `SELECT * FROM user_two_factor WHERE user_id = $1`
+	if err := r.db.DBX().GetContext(ctx, &out, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.TwoFactor{}, ErrTwoFactorNotFound
+		}
+		return model.TwoFactor{}, err
+	}
+
+	secret, err := r.decrypt(out.Secret)
+	if err != nil {
+		return model.TwoFactor{}, err
+	}
+
+	out.Secret = secret
+	return out, nil
+}
+
+// SaveTwoFactorSecret stores a pending secret, replacing any previous pending
+// one. Secrets of confirmed setups are left untouched.
+func (r TwoFactorRepo) SaveTwoFactorSecret(ctx context.Context, userID int, secret string) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorRepo.SaveTwoFactorSecret")
+	defer span.End()
+
+	encrypted, err := r.encrypt(secret)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.DBX().ExecContext(ctx, `INSERT INTO user_two_factor (user_id, secret) VALUES ($1, $2)
+                                          ON CONFLICT (user_id) DO UPDATE SET secret = $2, created_at = CURRENT_TIMESTAMP
+                                          WHERE user_two_factor.enabled = false`, userID, encrypted)
+	return err
+}
+
+func (r TwoFactorRepo) EnableTwoFactor(ctx context.Context, userID int, recoveryCodeHashes []string) (err error) {
+	ctx, span := tracing.Start(ctx, "TwoFactorRepo.EnableTwoFactor")
+	defer span.End()
+
+	tx, err := r.db.DBX().BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func(tx *sqlx.Tx) {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}(tx)
+
+	res, err := tx.ExecContext(ctx, `UPDATE user_two_factor SET enabled = true, confirmed_at = CURRENT_TIMESTAMP
+                                     WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		if err == nil {
+			err = ErrTwoFactorNotFound
+		}
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		if _, err = tx.ExecContext(ctx, `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r TwoFactorRepo) DisableTwoFactor(ctx context.Context, userID int) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorRepo.DisableTwoFactor")
+	defer span.End()
+
+	_, err := r.db.DBX().ExecContext(ctx, `WITH codes AS (DELETE FROM user_recovery_codes WHERE user_id = $1)
+                                           DELETE FROM user_two_factor WHERE user_id = $1`, userID)
+	return err
+}
+
+// UseRecoveryCode burns the recovery code matching codeHash.
+func (r TwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	ctx, span := tracing.Start(ctx, "TwoFactorRepo.UseRecoveryCode")
+	defer span.End()
+
+	res, err := r.db.DBX().ExecContext(ctx, `UPDATE user_recovery_codes SET used_at = CURRENT_TIMESTAMP
+                                             WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrInvalidRecoveryCode
+	}
+	return nil
+}
+
+func (r TwoFactorRepo) encrypt(plain string) (string, error) {
+	gcm, err := r.gcm()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (r TwoFactorRepo) decrypt(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := r.gcm()
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed two factor secret")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (r TwoFactorRepo) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(r.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
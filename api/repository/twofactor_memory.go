@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryTwoFactorRepo is a TwoFactorConnector keeping the totp secrets and
+// recovery codes in memory rather than in postgres. Nothing is persisted, so
+// unlike TwoFactorRepo the secrets are not encrypted.
+type MemoryTwoFactorRepo struct {
+	l      *logrus.Logger
+	tables *twoFactorTables
+}
+
+type twoFactorTables struct {
+	mu      sync.Mutex
+	secrets map[int]model.TwoFactor
+	// whether each recovery code hash of a user was used
+	recoveryCodes map[int]map[string]bool
+}
+
+func NewMemoryTwoFactorRepo(l *logrus.Logger) MemoryTwoFactorRepo {
+	return MemoryTwoFactorRepo{
+		l: l,
+		tables: &twoFactorTables{
+			secrets:       map[int]model.TwoFactor{},
+			recoveryCodes: map[int]map[string]bool{},
+		},
+	}
+}
+
+func (r MemoryTwoFactorRepo) GetTwoFactor(ctx context.Context, userID int) (model.TwoFactor, error) {
+	_, span := tracing.Start(ctx, "MemoryTwoFactorRepo.GetTwoFactor")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	out, ok := r.tables.secrets[userID]
+	if !ok {
+		return model.TwoFactor{}, ErrTwoFactorNotFound
+	}
+	return out, nil
+}
+
+// SaveTwoFactorSecret stores a pending secret, replacing any previous pending
+// one. Secrets of confirmed setups are left untouched.
+func (r MemoryTwoFactorRepo) SaveTwoFactorSecret(ctx context.Context, userID int, secret string) error {
+	_, span := tracing.Start(ctx, "MemoryTwoFactorRepo.SaveTwoFactorSecret")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	existing, ok := r.tables.secrets[userID]
+	if ok && existing.Enabled {
+		return nil
+	}
+
+	existing.UserID = userID
+	existing.Secret = secret
+	existing.CreatedAt = time.Now()
+
+	r.tables.secrets[userID] = existing
+	return nil
+}
+
+func (r MemoryTwoFactorRepo) EnableTwoFactor(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	_, span := tracing.Start(ctx, "MemoryTwoFactorRepo.EnableTwoFactor")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	existing, ok := r.tables.secrets[userID]
+	if !ok {
+		return ErrTwoFactorNotFound
+	}
+
+	now := time.Now()
+	existing.Enabled = true
+	existing.ConfirmedAt = &now
+	r.tables.secrets[userID] = existing
+
+	codes := map[string]bool{}
+	for _, hash := range recoveryCodeHashes {
+		codes[hash] = false
+	}
+	r.tables.recoveryCodes[userID] = codes
+
+	return nil
+}
+
+func (r MemoryTwoFactorRepo) DisableTwoFactor(ctx context.Context, userID int) error {
+	_, span := tracing.Start(ctx, "MemoryTwoFactorRepo.DisableTwoFactor")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	delete(r.tables.secrets, userID)
+	delete(r.tables.recoveryCodes, userID)
+	return nil
+}
+
+// UseRecoveryCode burns the recovery code matching codeHash.
+func (r MemoryTwoFactorRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	_, span := tracing.Start(ctx, "MemoryTwoFactorRepo.UseRecoveryCode")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	used, ok := r.tables.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return ErrInvalidRecoveryCode
+	}
+
+	r.tables.recoveryCodes[userID][codeHash] = true
+	return nil
+}
@@ -9,26 +9,29 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
-	"github.com/lib/pq"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/logger"
 	"github.com/muzz/api/pkg/pg"
+	"github.com/muzz/api/pkg/tracing"
 	"github.com/muzz/api/repository/model"
 	"github.com/sirupsen/logrus"
 )
 
-const (
-	uniqueConstraintCode pq.ErrorCode = "23505"
-)
-
 var (
-	ErrSwipeAlreadyExists = errors.New("swipe already exists")
+	ErrUserNotFound = errs.New(errs.NotFound, "user_not_found", "user not found")
 )
 
-//go:generate mockgen -destination=./mocks/mock_user_connector.go -package=mocks github.com/muzz/api/repository UserConnector
 type UserConnector interface {
 	CreateUser(ctx context.Context, user model.UserInput) (model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (model.User, error)
+	GetUserByPhone(ctx context.Context, phone string) (model.User, error)
+	GetUserByID(ctx context.Context, userID int) (model.User, error)
+	UpdatePassword(ctx context.Context, userID int, hashed string) error
+	UpdateUserRole(ctx context.Context, userID int, role string) (model.User, error)
+	VerifyEmail(ctx context.Context, userID int, email string) (model.User, error)
+	VerifyPhone(ctx context.Context, userID int, phone string) (model.User, error)
 	Swipe(ctx context.Context, userID, swipedUserID int, status bool) (model.Match, error)
-	Discover(ctx context.Context, userID int, age []int, gender string) ([]model.Discovery, error)
+	Discover(ctx context.Context, userID int, age []int, gender string, verifiedOnly bool) ([]model.Discovery, error)
 }
 
 type UserRepo struct {
@@ -44,39 +47,156 @@ func NewUserRepo(l *logrus.Logger, db *pg.Postgres) UserRepo {
 }
 
 func (r UserRepo) CreateUser(ctx context.Context, in model.UserInput) (model.User, error) {
-	query := `INSERT INTO users (email, password, name, gender, date_of_birth, location_lat, location_long) 
-              VALUES (:email, :password, :name, :gender, :date_of_birth, :location_lat, :location_long) RETURNING *`
+	ctx, span := tracing.Start(ctx, "UserRepo.CreateUser")
+	defer span.End()
+
+	query := `INSERT INTO users (email, phone, password, name, gender, date_of_birth, location_lat, location_long, role) 
+              VALUES (:email, :phone, :password, :name, :gender, :date_of_birth, :location_lat, :location_long, :role) RETURNING *`
 
 	var out model.User
-	stmt, err := r.db.DBX().PrepareNamed(query)
+	stmt, err := r.db.DBX().PrepareNamedContext(ctx, query)
 	if err != nil {
 		return out, err
 	}
 	defer stmt.Close()
 
-	err = stmt.Get(&out, in)
+	err = stmt.GetContext(ctx, &out, in)
 	if err != nil {
-		return out, err
+		return out, translate(err)
 	}
 
 	return out, nil
 }
 
 func (r UserRepo) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.GetUserByEmail")
+	defer span.End()
+
 	var out model.User
 
 	query := `SELECT * FROM users WHERE email = $1`
-	if err := r.db.DBX().Get(&out, query, email); err != nil {
+	if err := r.db.DBX().GetContext(ctx, &out, query, email); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return model.User{}, errors.New("user not found")
+			return model.User{}, ErrUserNotFound
 		}
 		return model.User{}, err
 	}
 	return out, nil
 }
 
+func (r UserRepo) GetUserByPhone(ctx context.Context, phone string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.GetUserByPhone")
+	defer span.End()
+
+	var out model.User
+
+	query := `SELECT * FROM users WHERE phone = $1`
+	if err := r.db.DBX().GetContext(ctx, &out, query, phone); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return out, nil
+}
+
+func (r UserRepo) GetUserByID(ctx context.Context, userID int) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.GetUserByID")
+	defer span.End()
+
+	var out model.User
+
+	query := `SELECT * FROM users WHERE id = $1`
+	if err := r.db.DBX().GetContext(ctx, &out, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return out, nil
+}
+
+func (r UserRepo) UpdatePassword(ctx context.Context, userID int, hashed string) error {
+	ctx, span := tracing.Start(ctx, "UserRepo.UpdatePassword")
+	defer span.End()
+
+	res, err := r.db.DBX().ExecContext(ctx, `UPDATE users SET password = $1 WHERE id = $2`, hashed, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r UserRepo) UpdateUserRole(ctx context.Context, userID int, role string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.UpdateUserRole")
+	defer span.End()
+
+	var out model.User
+
+	query := `UPDATE users SET role = $1 WHERE id = $2 RETURNING *`
+	if err := r.db.DBX().GetContext(ctx, &out, query, role, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return out, nil
+}
+
+// VerifyEmail flags the email of a user as verified, as long as it is still
+// the email the verification was issued for.
+func (r UserRepo) VerifyEmail(ctx context.Context, userID int, email string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.VerifyEmail")
+	defer span.End()
+
+	var out model.User
+
+	query := `UPDATE users SET email_verified = true WHERE id = $1 AND email = $2 RETURNING *`
+	if err := r.db.DBX().GetContext(ctx, &out, query, userID, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return out, nil
+}
+
+// VerifyPhone flags the phone number of a user as verified, as long as it is
+// still the number the one-time code was sent to.
+func (r UserRepo) VerifyPhone(ctx context.Context, userID int, phone string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.VerifyPhone")
+	defer span.End()
+
+	var out model.User
+
+	query := `UPDATE users SET phone_verified = true WHERE id = $1 AND phone = $2 RETURNING *`
+	if err := r.db.DBX().GetContext(ctx, &out, query, userID, phone); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, ErrUserNotFound
+		}
+		return model.User{}, err
+	}
+	return out, nil
+}
+
+// selectMatch selects the match between two users, in either order.
+const selectMatch = `SELECT id, user1_id, user2_id, created_at FROM matches
+                     WHERE (user1_id = $1 AND user2_id = $2) OR (user1_id = $2 AND user2_id = $1)`
+
 func (r UserRepo) Swipe(ctx context.Context, userID, swipedUserID int, status bool) (model.Match, error) {
-	tx, err := r.db.DBX().Beginx()
+	ctx, span := tracing.Start(ctx, "UserRepo.Swipe")
+	defer span.End()
+
+	tx, err := r.db.DBX().BeginTxx(ctx, nil)
 	if err != nil {
 		return model.Match{}, err
 	}
@@ -108,9 +228,7 @@ func (r UserRepo) Swipe(ctx context.Context, userID, swipedUserID int, status bo
 		"created_at":     swipe.CreatedAt,
 	})
 	if err != nil {
-		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == uniqueConstraintCode {
-			return model.Match{}, ErrSwipeAlreadyExists
-		}
+		err = translate(err)
 		return model.Match{}, err
 	}
 
@@ -127,12 +245,22 @@ func (r UserRepo) Swipe(ctx context.Context, userID, swipedUserID int, status bo
 			User2ID:   swipedUserID,
 			CreatedAt: time.Now(),
 		}
-		err = tx.GetContext(ctx, &match, `INSERT INTO matches (user1_id, user2_id, created_at) 
-                                           VALUES ($1, $2, $3) 
-                                           ON CONFLICT (user1_id, user2_id) DO NOTHING 
-                                           RETURNING id, user1_id, user2_id, created_at`, match.User1ID, match.User2ID, match.CreatedAt)
+		// swiping again on a match reports the existing one, whichever of
+		// the two users completed it
+		err = tx.GetContext(ctx, &match, selectMatch, match.User1ID, match.User2ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tx.GetContext(ctx, &match, `INSERT INTO matches (user1_id, user2_id, created_at) 
+                                               VALUES ($1, $2, $3) 
+                                               ON CONFLICT (user1_id, user2_id) DO NOTHING 
+                                               RETURNING id, user1_id, user2_id, created_at`, match.User1ID, match.User2ID, match.CreatedAt)
+			match.Created = err == nil
+			if errors.Is(err, sql.ErrNoRows) {
+				// recorded meanwhile by a concurrent swipe
+				err = tx.GetContext(ctx, &match, selectMatch, match.User1ID, match.User2ID)
+			}
+		}
 		if err != nil {
-			fmt.Println("syntax err", err)
+			logger.FromContext(ctx, r.l).WithError(err).Error("failed to record match")
 			return model.Match{}, err
 		}
 
@@ -143,7 +271,10 @@ func (r UserRepo) Swipe(ctx context.Context, userID, swipedUserID int, status bo
 	return model.Match{}, nil
 }
 
-func (r UserRepo) Discover(ctx context.Context, userID int, age []int, gender string) ([]model.Discovery, error) {
+func (r UserRepo) Discover(ctx context.Context, userID int, age []int, gender string, verifiedOnly bool) ([]model.Discovery, error) {
+	ctx, span := tracing.Start(ctx, "UserRepo.Discover")
+	defer span.End()
+
 	var results []model.Discovery
 
 	// Get the current user's location
@@ -187,6 +318,10 @@ func (r UserRepo) Discover(ctx context.Context, userID int, age []int, gender st
 		query = query.Where(fmt.Sprintf("u.gender = '%s'", gender))
 	}
 
+	if verifiedOnly {
+		query = query.Where("(u.email_verified = true OR u.phone_verified = true)")
+	}
+
 	sql, _, err := query.ToSql()
 	if err != nil {
 		return nil, err
@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+// earthRadius is the radius in meters used by the postgres earthdistance
+// extension, distances match the ones computed by UserRepo.
+const earthRadius = 6378168
+
+// roles allowed by the users_role_check constraint
+var roles = map[string]bool{"user": true, "moderator": true, "admin": true}
+
+// MemoryUserRepo is a UserConnector keeping users, swipes and matches in
+// memory rather than in postgres. It enforces the constraints of the schema
+// and ranks discoveries the way UserRepo does.
+type MemoryUserRepo struct {
+	l      *logrus.Logger
+	tables *userTables
+}
+
+type userTables struct {
+	mu sync.RWMutex
+	// users are never deleted, a user's id is its index plus one
+	users   []model.User
+	emails  map[string]int64
+	phones  map[string]int64
+	swipes  map[[2]int]model.Swipe
+	matches map[[2]int]model.Match
+	// ids of the last swipe and match
+	swipeID int
+	matchID int
+}
+
+func NewMemoryUserRepo(l *logrus.Logger) MemoryUserRepo {
+	return MemoryUserRepo{
+		l: l,
+		tables: &userTables{
+			emails:  map[string]int64{},
+			phones:  map[string]int64{},
+			swipes:  map[[2]int]model.Swipe{},
+			matches: map[[2]int]model.Match{},
+		},
+	}
+}
+
+func (r MemoryUserRepo) CreateUser(ctx context.Context, in model.UserInput) (model.User, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.CreateUser")
+	defer span.End()
+
+	dob, err := time.Parse(time.DateOnly, in.DOB)
+	if err != nil {
+		return model.User{}, ErrInvalidData.Wrap(err)
+	}
+
+	if (in.Email == nil && in.Phone == nil) || !roles[in.Role] ||
+		tooLong(in.Email, 255) || tooLong(in.Phone, 16) || tooLong(&in.Password, 255) ||
+		tooLong(&in.Name, 255) || tooLong(&in.Gender, 50) {
+		return model.User{}, ErrInvalidData
+	}
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	if in.Email != nil {
+		if _, ok := r.tables.emails[*in.Email]; ok {
+			return model.User{}, ErrEmailTaken
+		}
+	}
+
+	if in.Phone != nil {
+		if _, ok := r.tables.phones[*in.Phone]; ok {
+			return model.User{}, ErrPhoneTaken
+		}
+	}
+
+	user := model.User{
+		ID:           int64(len(r.tables.users)) + 1,
+		Email:        clone(in.Email),
+		Phone:        clone(in.Phone),
+		Password:     in.Password,
+		Name:         in.Name,
+		Gender:       in.Gender,
+		DOB:          dob,
+		LocationLat:  clone(in.LocationLat),
+		LocationLong: clone(in.LocationLong),
+		Role:         in.Role,
+	}
+
+	r.tables.users = append(r.tables.users, user)
+	if user.Email != nil {
+		r.tables.emails[*user.Email] = user.ID
+	}
+	if user.Phone != nil {
+		r.tables.phones[*user.Phone] = user.ID
+	}
+
+	return user, nil
+}
+
+func (r MemoryUserRepo) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.GetUserByEmail")
+	defer span.End()
+
+	r.tables.mu.RLock()
+	defer r.tables.mu.RUnlock()
+
+	id, ok := r.tables.emails[email]
+	if !ok {
+		return model.User{}, ErrUserNotFound
+	}
+	return r.tables.users[id-1], nil
+}
+
+func (r MemoryUserRepo) GetUserByPhone(ctx context.Context, phone string) (model.User, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.GetUserByPhone")
+	defer span.End()
+
+	r.tables.mu.RLock()
+	defer r.tables.mu.RUnlock()
+
+	id, ok := r.tables.phones[phone]
+	if !ok {
+		return model.User{}, ErrUserNotFound
+	}
+	return r.tables.users[id-1], nil
+}
+
+func (r MemoryUserRepo) GetUserByID(ctx context.Context, userID int) (model.User, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.GetUserByID")
+	defer span.End()
+
+	r.tables.mu.RLock()
+	defer r.tables.mu.RUnlock()
+
+	user, ok := r.tables.user(userID)
+	if !ok {
+		return model.User{}, ErrUserNotFound
+	}
+	return *user, nil
+}
+
+func (r MemoryUserRepo) UpdatePassword(ctx context.Context, userID int, hashed string) error {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.UpdatePassword")
+	defer span.End()
+
+	if tooLong(&hashed, 255) {
+		return ErrInvalidData
+	}
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	user, ok := r.tables.user(userID)
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	user.Password = hashed
+	return nil
+}
+
+func (r MemoryUserRepo) UpdateUserRole(ctx context.Context, userID int, role string) (model.User, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.UpdateUserRole")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	user, ok := r.tables.user(userID)
+	if !ok {
+		return model.User{}, ErrUserNotFound
+	}
+
+	if !roles[role] {
+		return model.User{}, ErrInvalidData
+	}
+
+	user.Role = role
+	return *user, nil
+}
+
+// VerifyEmail flags the email of a user as verified, as long as it is still
+// the email the verification was issued for.
+func (r MemoryUserRepo) VerifyEmail(ctx context.Context, userID int, email string) (model.User, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.VerifyEmail")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	user, ok := r.tables.user(userID)
+	if !ok || user.Email == nil || *user.Email != email {
+		return model.User{}, ErrUserNotFound
+	}
+
+	user.EmailVerified = true
+	return *user, nil
+}
+
+// VerifyPhone flags the phone number of a user as verified, as long as it is
+// still the number the one-time code was sent to.
+func (r MemoryUserRepo) VerifyPhone(ctx context.Context, userID int, phone string) (model.User, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.VerifyPhone")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	user, ok := r.tables.user(userID)
+	if !ok || user.Phone == nil || *user.Phone != phone {
+		return model.User{}, ErrUserNotFound
+	}
+
+	user.PhoneVerified = true
+	return *user, nil
+}
+
+func (r MemoryUserRepo) Swipe(ctx context.Context, userID, swipedUserID int, status bool) (model.Match, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.Swipe")
+	defer span.End()
+
+	r.tables.mu.Lock()
+	defer r.tables.mu.Unlock()
+
+	if _, ok := r.tables.user(userID); !ok {
+		return model.Match{}, ErrUserNotFound
+	}
+	if _, ok := r.tables.user(swipedUserID); !ok {
+		return model.Match{}, ErrUserNotFound
+	}
+
+	// swiping again only changes the status of the swipe
+	key := [2]int{userID, swipedUserID}
+	swipe, ok := r.tables.swipes[key]
+	if !ok {
+		r.tables.swipeID++
+		swipe = model.Swipe{
+			ID:           r.tables.swipeID,
+			UserID:       userID,
+			SwipedUserID: swipedUserID,
+			CreatedAt:    time.Now(),
+		}
+	}
+	swipe.SwipeStatus = status
+	r.tables.swipes[key] = swipe
+
+	back := r.tables.swipes[[2]int{swipedUserID, userID}]
+	if !status || userID == swipedUserID || !back.SwipeStatus {
+		return model.Match{}, nil
+	}
+
+	// swiping again on a match reports the existing one, whichever of the
+	// two users completed it
+	match, ok := r.tables.matches[key]
+	if !ok {
+		match, ok = r.tables.matches[[2]int{swipedUserID, userID}]
+	}
+	if !ok {
+		r.tables.matchID++
+		match = model.Match{
+			ID:        r.tables.matchID,
+			User1ID:   userID,
+			User2ID:   swipedUserID,
+			CreatedAt: time.Now(),
+		}
+		r.tables.matches[key] = match
+	}
+
+	match.IsMatch = true
+	match.Created = !ok
+	return match, nil
+}
+
+// Discover returns the users not swiped nor matched yet by userID, closest
+// first then most liked first.
+func (r MemoryUserRepo) Discover(ctx context.Context, userID int, age []int, gender string, verifiedOnly bool) ([]model.Discovery, error) {
+	_, span := tracing.Start(ctx, "MemoryUserRepo.Discover")
+	defer span.End()
+
+	r.tables.mu.RLock()
+	defer r.tables.mu.RUnlock()
+
+	me, ok := r.tables.user(userID)
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	likes := map[int]int{}
+	excluded := map[int]bool{userID: true}
+	for key, swipe := range r.tables.swipes {
+		if swipe.SwipeStatus {
+			likes[key[1]]++
+		}
+		if key[0] == userID {
+			excluded[key[1]] = true
+		}
+	}
+
+	for key := range r.tables.matches {
+		if key[0] == userID {
+			excluded[key[1]] = true
+		}
+		if key[1] == userID {
+			excluded[key[0]] = true
+		}
+	}
+
+	now := time.Now()
+	lat, long := value(me.LocationLat), value(me.LocationLong)
+
+	results := []model.Discovery{}
+	for _, u := range r.tables.users {
+		if excluded[int(u.ID)] {
+			continue
+		}
+
+		if len(age) == 2 {
+			if years := yearsSince(u.DOB, now); years < age[0] || years > age[1] {
+				continue
+			}
+		}
+
+		if gender != "" && u.Gender != gender {
+			continue
+		}
+
+		if verifiedOnly && !u.EmailVerified && !u.PhoneVerified {
+			continue
+		}
+
+		results = append(results, model.Discovery{
+			User: model.User{
+				ID:           u.ID,
+				Name:         u.Name,
+				Email:        u.Email,
+				Gender:       u.Gender,
+				DOB:          u.DOB,
+				LocationLat:  u.LocationLat,
+				LocationLong: u.LocationLong,
+			},
+			DistanceFromMe:      haversine(lat, long, value(u.LocationLat), value(u.LocationLong)),
+			AttractivenessScore: likes[int(u.ID)],
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.DistanceFromMe != b.DistanceFromMe {
+			return a.DistanceFromMe < b.DistanceFromMe
+		}
+		if a.AttractivenessScore != b.AttractivenessScore {
+			return a.AttractivenessScore > b.AttractivenessScore
+		}
+		return a.User.ID < b.User.ID
+	})
+
+	return results, nil
+}
+
+// user returns the stored user userID, to be updated in place.
+func (t *userTables) user(userID int) (*model.User, bool) {
+	if userID < 1 || userID > len(t.users) {
+		return nil, false
+	}
+	return &t.users[userID-1], true
+}
+
+// haversine returns the great circle distance in meters between two points,
+// missing coordinates counting as 0 as they do in UserRepo.
+func haversine(lat1, long1, lat2, long2 float64) float64 {
+	rad := math.Pi / 180
+
+	dLat := (lat2 - lat1) * rad
+	dLong := (long2 - long1) * rad
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLong/2)*math.Sin(dLong/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// yearsSince returns the number of full years between t and now.
+func yearsSince(t, now time.Time) int {
+	years := now.Year() - t.Year()
+	if now.Month() < t.Month() || (now.Month() == t.Month() && now.Day() < t.Day()) {
+		years--
+	}
+	return years
+}
+
+func tooLong(s *string, max int) bool {
+	return s != nil && len([]rune(*s)) > max
+}
+
+func clone[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+
+	c := *v
+	return &c
+}
+
+func value[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
@@ -0,0 +1,322 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/repository/model"
+	"github.com/sirupsen/logrus"
+)
+
+// a tenth of a degree along the equator, with the earth radius of the
+// postgres earthdistance extension
+const tenthOfDegree = 6378168 * math.Pi / 1800
+
+func TestMemoryUserRepoDiscover(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewMemoryUserRepo(discard())
+
+	users := []struct {
+		gender    string
+		age       int
+		lat, long float64
+		verified  bool
+	}{
+		{gender: "M", age: 30, lat: 0, long: 0},                   // 1, discovering
+		{gender: "F", age: 25, lat: 0, long: 0.1},                 // 2
+		{gender: "M", age: 35, lat: 0.1, long: 0, verified: true}, // 3, as close as 2 but liked once
+		{gender: "F", age: 30, lat: 0, long: 1, verified: true},   // 4, liked twice but ten times further
+		{gender: "F", age: 28, lat: 0, long: 0.05},                // 5, swiped by 1
+		{gender: "F", age: 28, lat: 0, long: 0.05},                // 6, matched with 1
+		{gender: "F", age: 45, lat: 0, long: 0.1},                 // 7, tied with 2
+	}
+
+	for i, u := range users {
+		lat, long := u.lat, u.long
+		created := createUser(t, r, model.UserInput{
+			Name:         fmt.Sprintf("user%d", i+1),
+			Gender:       u.gender,
+			DOB:          time.Now().AddDate(-u.age, 0, -1).Format(time.DateOnly),
+			LocationLat:  &lat,
+			LocationLong: &long,
+		})
+
+		if u.verified {
+			if _, err := r.VerifyEmail(ctx, int(created.ID), *created.Email); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, s := range []struct {
+		user, swiped int
+		status       bool
+	}{
+		{1, 5, false},
+		{5, 3, true},
+		{5, 4, true},
+		{6, 4, true},
+		{6, 1, true},
+		{1, 6, true},
+	} {
+		if _, err := r.Swipe(ctx, s.user, s.swiped, s.status); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		age       []int
+		gender    string
+		verified  bool
+		want      []int64
+		distances []float64
+	}{
+		{
+			name:      "closest first then most liked",
+			want:      []int64{3, 2, 7, 4},
+			distances: []float64{tenthOfDegree, tenthOfDegree, tenthOfDegree, 10 * tenthOfDegree},
+		},
+		{
+			name: "age range",
+			age:  []int{25, 30},
+			want: []int64{2, 4},
+		},
+		{
+			name:   "gender",
+			gender: "F",
+			want:   []int64{2, 7, 4},
+		},
+		{
+			name:     "verified only",
+			verified: true,
+			want:     []int64{3, 4},
+		},
+		{
+			name:     "every filter",
+			age:      []int{18, 99},
+			gender:   "F",
+			verified: true,
+			want:     []int64{4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Discover(ctx, 1, tt.age, tt.gender, tt.verified)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := make([]int64, len(got))
+			for i, d := range got {
+				ids[i] = d.User.ID
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Fatalf("got users %v, want %v", ids, tt.want)
+			}
+
+			for i, want := range tt.distances {
+				if math.Abs(got[i].DistanceFromMe-want) > 0.01 {
+					t.Errorf("user %d: got distance %f, want %f", ids[i], got[i].DistanceFromMe, want)
+				}
+			}
+		})
+	}
+
+	if _, err := r.Discover(ctx, 99, nil, "", false); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Fatalf("unknown user: got %v, want %v", err, repository.ErrUserNotFound)
+	}
+}
+
+func TestMemoryUserRepoSwipe(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewMemoryUserRepo(discard())
+
+	for i := 0; i < 3; i++ {
+		createUser(t, r, model.UserInput{Name: fmt.Sprintf("user%d", i+1)})
+	}
+
+	// every step runs against the swipes of the previous ones
+	steps := []struct {
+		name         string
+		user, swiped int
+		status       bool
+		want         model.Match
+		err          error
+	}{
+		{name: "first like", user: 1, swiped: 2, status: true},
+		{name: "like back", user: 2, swiped: 1, status: true, want: model.Match{ID: 1, User1ID: 2, User2ID: 1, IsMatch: true, Created: true}},
+		{name: "like again", user: 2, swiped: 1, status: true, want: model.Match{ID: 1, User1ID: 2, User2ID: 1, IsMatch: true}},
+		{name: "like again the other way", user: 1, swiped: 2, status: true, want: model.Match{ID: 1, User1ID: 2, User2ID: 1, IsMatch: true}},
+		{name: "dislike", user: 1, swiped: 3, status: false},
+		{name: "like a user who disliked", user: 3, swiped: 1, status: true},
+		{name: "change a dislike into a like", user: 1, swiped: 3, status: true, want: model.Match{ID: 2, User1ID: 1, User2ID: 3, IsMatch: true, Created: true}},
+		{name: "unknown user", user: 1, swiped: 99, status: true, err: repository.ErrUserNotFound},
+	}
+
+	for _, step := range steps {
+		got, err := r.Swipe(ctx, step.user, step.swiped, step.status)
+		if !errors.Is(err, step.err) {
+			t.Fatalf("%s: got error %v, want %v", step.name, err, step.err)
+		}
+
+		got.CreatedAt = time.Time{}
+		if got != step.want {
+			t.Fatalf("%s: got %+v, want %+v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestMemoryUserRepoConcurrentSwipes(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewMemoryUserRepo(discard())
+
+	const n = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			email := fmt.Sprintf("user%d@muzz.com", i+1)
+			if _, err := r.CreateUser(ctx, withDefaults(model.UserInput{Email: &email})); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every user likes every other one while discovering, each pair
+	// matching exactly once whoever likes last
+	var mu sync.Mutex
+	created := 0
+	for user := 1; user <= n; user++ {
+		for swiped := 1; swiped <= n; swiped++ {
+			if user == swiped {
+				continue
+			}
+
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+
+				match, err := r.Swipe(ctx, user, swiped, true)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if match.Created {
+					mu.Lock()
+					created++
+					mu.Unlock()
+				}
+			}()
+			go func() {
+				defer wg.Done()
+
+				if _, err := r.Discover(ctx, user, nil, "", false); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if want := n * (n - 1) / 2; created != want {
+		t.Fatalf("got %d matches, want %d", created, want)
+	}
+
+	for user := 1; user <= n; user++ {
+		got, err := r.Discover(ctx, user, nil, "", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("user %d: got %d discoveries, want none", user, len(got))
+		}
+	}
+}
+
+func TestMemoryUserRepoCreateUser(t *testing.T) {
+	ctx := context.Background()
+	r := repository.NewMemoryUserRepo(discard())
+
+	email, phone := "a@a.com", "+447700900123"
+	long := strings.Repeat("a", 256)
+
+	tests := []struct {
+		name string
+		in   model.UserInput
+		err  error
+	}{
+		{name: "email", in: model.UserInput{Email: &email}},
+		{name: "phone", in: model.UserInput{Phone: &phone}},
+		{name: "email taken", in: model.UserInput{Email: &email}, err: repository.ErrEmailTaken},
+		{name: "phone taken", in: model.UserInput{Phone: &phone}, err: repository.ErrPhoneTaken},
+		{name: "neither email nor phone", in: model.UserInput{}, err: repository.ErrInvalidData},
+		{name: "unknown role", in: model.UserInput{Email: ptr("b@b.com"), Role: "owner"}, err: repository.ErrInvalidData},
+		{name: "name too long", in: model.UserInput{Email: ptr("c@c.com"), Name: long}, err: repository.ErrInvalidData},
+		{name: "invalid date of birth", in: model.UserInput{Email: ptr("d@d.com"), DOB: "01/01/2000"}, err: repository.ErrInvalidData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := r.CreateUser(ctx, withDefaults(tt.in)); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// createUser creates a user with an email derived from its name and the
+// fields missing from in filled in.
+func createUser(t *testing.T, r repository.MemoryUserRepo, in model.UserInput) model.User {
+	t.Helper()
+
+	if in.Email == nil && in.Phone == nil {
+		in.Email = ptr(fmt.Sprintf("%s@muzz.com", in.Name))
+	}
+
+	user, err := r.CreateUser(context.Background(), withDefaults(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func withDefaults(in model.UserInput) model.UserInput {
+	if in.Password == "" {
+		in.Password = "hashed"
+	}
+	if in.Gender == "" {
+		in.Gender = "M"
+	}
+	if in.DOB == "" {
+		in.DOB = "2000-01-01"
+	}
+	if in.Role == "" {
+		in.Role = "user"
+	}
+	return in
+}
+
+func discard() *logrus.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+)
+
+const verifyEmailPurpose = "verify_email"
+
+var (
+	ErrInvalidVerificationToken = errs.New(errs.Validation, "invalid_verification_token", "invalid or expired verification token")
+)
+
+// GenerateVerificationToken signs a token proving ownership of email by uid.
+// The token id is kept in the cache for ttl so that it can be redeemed once.
+func (a AuthRepo) GenerateVerificationToken(ctx context.Context, uid int, email string, ttl time.Duration) (string, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.GenerateVerificationToken")
+	defer span.End()
+
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := a.signToken(model.VerificationClaims{
+		ID:      id,
+		UserID:  uid,
+		Email:   email,
+		Purpose: verifyEmailPurpose,
+		Expires: time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.cache.WithContext(ctx).Set(verificationKey(id), uid, ttl).Err(); err != nil {
+		return "", err
+	}
+
+	return signed, nil
+}
+
+func (a AuthRepo) ConsumeVerificationToken(ctx context.Context, token string) (model.VerificationClaims, error) {
+	ctx, span := tracing.Start(ctx, "AuthRepo.ConsumeVerificationToken")
+	defer span.End()
+
+	out, ok := a.parseVerification(token, verifyEmailPurpose)
+	if !ok {
+		return out, ErrInvalidVerificationToken
+	}
+
+	// deleting the id makes the token single use
+	deleted, err := a.cache.WithContext(ctx).Del(verificationKey(out.ID)).Result()
+	if err != nil {
+		return out, err
+	}
+
+	if deleted == 0 {
+		return out, ErrInvalidVerificationToken
+	}
+
+	return out, nil
+}
+
+// parseVerification decodes the claims of a verification token signed for
+// purpose, reporting whether it is valid and not expired.
+func (t tokens) parseVerification(token, purpose string) (model.VerificationClaims, bool) {
+	var out model.VerificationClaims
+
+	claims, err := t.parseToken(token)
+	if err != nil {
+		return out, false
+	}
+
+	if err := mapstructure.Decode(claims, &out); err != nil {
+		return out, false
+	}
+
+	if out.Purpose != purpose || out.Expires < time.Now().Unix() {
+		return out, false
+	}
+	return out, true
+}
+
+func verificationKey(id string) string {
+	return fmt.Sprintf("%s:%s", verifyEmailPurpose, id)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/rest/middleware"
+)
+
+// maxBodyBytes caps request bodies, the largest payloads are passkey
+// attestations which stay well below it.
+const maxBodyBytes = 1 << 20
+
+var (
+	errBodyTooLarge     = errs.New(errs.TooLarge, "body_too_large", "request body is too large")
+	errUnsupportedMedia = errs.New(errs.UnsupportedMediaType, "unsupported_media_type", "request body must be application/json")
+)
+
+// NoBody is the input of endpoints that take no request body.
+type NoBody struct{}
+
+// NoContent is the output of endpoints that answer without a body.
+type NoContent struct{}
+
+// Request is what typed handlers get: the http request along with its
+// decoded and validated body and, on authenticated routes, the user id.
+type Request[In any] struct {
+	*http.Request
+	Input  In
+	UserID int
+}
+
+type handleOptions struct {
+	status        int
+	authenticated bool
+}
+
+type HandleOption func(*handleOptions)
+
+// WithStatus overrides the status of successful responses, 200 by default
+// and 204 for NoContent outputs.
+func WithStatus(status int) HandleOption {
+	return func(o *handleOptions) {
+		o.status = status
+	}
+}
+
+// Authenticated requires the user id set by the auth middleware, requests
+// without one are rejected before reaching the handler.
+func Authenticated() HandleOption {
+	return func(o *handleOptions) {
+		o.authenticated = true
+	}
+}
+
+// Handle adapts a typed handler to http. The json body is decoded strictly
+// (unknown fields, trailing data and bodies over maxBodyBytes are rejected,
+// the Content-Type must be application/json) and validated before fn is
+// called. Its output is encoded as json and its errors written as problems.
+func Handle[In, Out any](h Handler, fn func(Request[In]) (Out, error), opts ...HandleOption) http.HandlerFunc {
+	var o handleOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := Request[In]{Request: r}
+
+		if o.authenticated {
+			userID, err := middleware.GetUserIDFromContext(r.Context())
+			if err != nil {
+				WriteError(w, r, err)
+				return
+			}
+			req.UserID = userID
+		}
+
+		if _, ok := any(req.Input).(NoBody); !ok {
+			if err := h.decode(w, r, &req.Input); err != nil {
+				h.writeError(w, r, err)
+				return
+			}
+		}
+
+		out, err := fn(req)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		h.encode(w, r, o.status, out)
+	}
+}
+
+func (h Handler) decode(w http.ResponseWriter, r *http.Request, in any) error {
+	defer r.Body.Close()
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return errUnsupportedMedia
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(in); err != nil {
+		return decodeError(err)
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errInvalidBody.WithDetail("unexpected data after the json value")
+	}
+
+	return h.validator.Struct(in)
+}
+
+func decodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errBodyTooLarge
+	}
+
+	if errors.Is(err, io.EOF) {
+		return errInvalidBody.WithDetail("request body is empty")
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return errInvalidBody.WithDetail("request body is truncated")
+	}
+
+	// syntax errors, mistyped and unknown fields
+	return errInvalidBody.WithDetail(err.Error())
+}
+
+func (h Handler) encode(w http.ResponseWriter, r *http.Request, status int, out any) {
+	if _, ok := out.(NoContent); ok {
+		if status == 0 {
+			status = http.StatusNoContent
+		}
+		w.WriteHeader(status)
+		return
+	}
+
+	jsonOut, err := json.Marshal(out)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(jsonOut); err != nil {
+		h.log.WithError(err).Error("failed to write response")
+	}
+}
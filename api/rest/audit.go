@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/muzz/api/pkg/slice"
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/rest/transformer"
+	"github.com/muzz/api/service/entity"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// GetAuditEvents godoc
+//
+// @Summary      Query the audit log
+// @Description  List authentication and account events, newest first, restricted to admins
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}  definition.AuditEvent
+// @Router       /admin/audit [get]
+//
+// @Param        user_id  query  int     false  "events the user performed or was the subject of"
+// @Param        from     query  string  false  "RFC 3339 time, inclusive"
+// @Param        to       query  string  false  "RFC 3339 time, exclusive"
+// @Param        limit    query  int     false  "maximum number of events, 100 by default and at most 1000"
+func (h Handler) GetAuditEvents(r Request[NoBody]) ([]definition.AuditEvent, error) {
+	filter, err := getAuditFilter(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := h.userConn.GetAuditEvents(r.Context(), filter)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(out, transformer.FromAuditEventEntityToDef), nil
+}
+
+func getAuditFilter(query url.Values) (entity.AuditFilter, error) {
+	filter := entity.AuditFilter{Limit: defaultAuditLimit}
+
+	if v := query.Get("user_id"); v != "" {
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errInvalidParam.WithDetail("user_id must be a number")
+		}
+		filter.UserID = &userID
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errInvalidParam.WithDetail("from must be an RFC 3339 time")
+		}
+		filter.From = &from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errInvalidParam.WithDetail("to must be an RFC 3339 time")
+		}
+		filter.To = &to
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || limit == 0 || limit > maxAuditLimit {
+			return filter, errInvalidParam.WithDetail("limit must be a number between 1 and 1000")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,17 @@
+package definition
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEvent struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	ActorID   *int            `json:"actor_id,omitempty"`
+	UserID    *int            `json:"user_id,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	Details   json.RawMessage `json:"details" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
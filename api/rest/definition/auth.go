@@ -1,11 +1,77 @@
 package definition
 
+import "encoding/json"
+
 type LoginInput struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
 
+type UnlockInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type MagicLinkInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type MagicLinkRedeemInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type OTPInput struct {
+	Phone string `json:"phone" validate:"required,e164"`
+}
+
+type OTPLoginInput struct {
+	Phone string `json:"phone" validate:"required,e164"`
+	Code  string `json:"code" validate:"required,numeric,len=6"`
+}
+
 type Token struct {
-	Token   string `json:"token"`
-	Expires int64  `json:"expires"`
+	Token             string `json:"token"`
+	Expires           int64  `json:"expires"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+}
+
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type PasskeyCeremony struct {
+	SessionID string          `json:"session_id"`
+	Options   json.RawMessage `json:"options" swaggertype:"object"`
+}
+
+type PasskeyFinishInput struct {
+	SessionID  string          `json:"session_id" validate:"required"`
+	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordInput struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
 }
@@ -1,7 +1,10 @@
 package definition
 
+import "time"
+
 type UserInput struct {
-	Email        string   `json:"email" validate:"required,email"`
+	Email        string   `json:"email" validate:"required_without=Phone,omitempty,email"`
+	Phone        string   `json:"phone" validate:"required_without=Email,omitempty,e164"`
 	Password     string   `json:"password" validate:"required"` // implement hash
 	Name         string   `json:"name" validate:"required"`
 	Gender       string   `json:"gender" validate:"oneof=M F"`
@@ -11,14 +14,36 @@ type UserInput struct {
 }
 
 type User struct {
-	ID           int64    `json:"id"`
-	Email        string   `json:"email"`
-	Password     string   `json:"password"`
-	Name         string   `json:"name"`
-	Gender       string   `json:"gender"`
-	Age          int      `json:"age"`
-	LocationLat  *float64 `json:"location_lat,omitempty"`
-	LocationLong *float64 `json:"location_long,omitempty"`
+	ID            int64    `json:"id"`
+	Email         string   `json:"email"`
+	Phone         string   `json:"phone,omitempty"`
+	Password      string   `json:"password,omitempty"`
+	Name          string   `json:"name"`
+	Gender        string   `json:"gender"`
+	Age           int      `json:"age"`
+	LocationLat   *float64 `json:"location_lat,omitempty"`
+	LocationLong  *float64 `json:"location_long,omitempty"`
+	Role          string   `json:"role"`
+	EmailVerified bool     `json:"email_verified"`
+	PhoneVerified bool     `json:"phone_verified"`
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name,omitempty"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+type VerifyInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type RoleInput struct {
+	Role string `json:"role" validate:"oneof=user moderator admin"`
 }
 
 type SwipeInput struct {
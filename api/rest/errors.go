@@ -0,0 +1,26 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/logger"
+)
+
+var (
+	errInvalidBody  = errs.New(errs.Validation, "invalid_body", "invalid request body")
+	errInvalidParam = errs.New(errs.Validation, "invalid_parameter", "invalid parameter")
+)
+
+// writeError reports err as a problem. Errors that are neither domain nor
+// validation errors are unexpected, they are logged since clients only get a
+// generic message.
+func (h Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErrs validator.ValidationErrors
+	if errs.KindOf(err) == errs.Internal && !errors.As(err, &validationErrs) {
+		logger.FromContext(r.Context(), h.log).Error(err)
+	}
+	WriteError(w, r, err)
+}
@@ -1,16 +1,14 @@
 package rest
 
 import (
-	"encoding/json"
-	"errors"
-	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/muzz/api/pkg/health"
+	"github.com/muzz/api/pkg/i18n"
 	"github.com/muzz/api/pkg/slice"
 	"github.com/muzz/api/rest/definition"
-	"github.com/muzz/api/rest/middleware"
 	"github.com/muzz/api/rest/transformer"
 	"github.com/muzz/api/service"
 	"github.com/sirupsen/logrus"
@@ -20,40 +18,30 @@ type Handler struct {
 	log       *logrus.Logger
 	userConn  service.UserConnector
 	validator *validator.Validate
+	readiness *health.Readiness
+	checker   health.Checker
 }
 
 func NewHandler(
 	log *logrus.Logger,
 	userConn service.UserConnector,
-) Handler {
-	v := validator.New(
-		validator.WithRequiredStructEnabled(),
-	)
+	translator *i18n.Translator,
+	readiness *health.Readiness,
+	checker health.Checker,
+) (Handler, error) {
+	v := NewValidator()
 
-	_ = v.RegisterValidation("dob", DOBValidator)
+	if err := translator.RegisterValidator(v); err != nil {
+		return Handler{}, err
+	}
 
 	return Handler{
 		log:       log,
 		userConn:  userConn,
 		validator: v,
-	}
-}
-
-// Healthz godoc
-//
-//  @Summary      Check service health
-//  @Description  Check service health condition
-//  @Tags         health
-//  @Produce      plain
-//  @Success      200  {string}  string  "OK"
-//  @Router       /healthz [get]
-func (h Handler) Health(writer http.ResponseWriter, request *http.Request) {
-	writer.WriteHeader(http.StatusOK)
-
-	if _, err := writer.Write([]byte(`OK`)); err != nil {
-		http.Error(writer, err.Error(), http.StatusInternalServerError)
-		return
-	}
+		readiness: readiness,
+		checker:   checker,
+	}, nil
 }
 
 // CreateUser godoc
@@ -66,98 +54,65 @@ func (h Handler) Health(writer http.ResponseWriter, request *http.Request) {
 // @Router       /user/create [post]
 //
 // @Param        user  body  definition.UserInput  true  "user to create"
-func (h Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	defer r.Body.Close()
-
-	b, err := io.ReadAll(r.Body)
+func (h Handler) CreateUser(r Request[definition.UserInput]) (definition.User, error) {
+	out, err := h.userConn.CreateUser(r.Context(), transformer.FromUserInputDefToEntity(r.Input))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		WriteError(w, errors.New("failed to read request body"))
-		return
+		return definition.User{}, err
 	}
 
-	var user definition.UserInput
-	if err = json.Unmarshal(b, &user); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	if err := h.validator.Struct(user); err != nil {
-		h.log.Error(err)
-		w.WriteHeader(http.StatusBadRequest)
-		WriteError(w, err)
-		return
-	}
-
-	out, err := h.userConn.CreateUser(r.Context(), transformer.FromUserInputDefToEntity(user))
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		WriteError(w, err)
-		return
-	}
+	// only the account creation hands the password hash back
+	user := transformer.FromUserEntityToDef(out)
+	user.Password = out.Password
+	return user, nil
+}
 
-	jsonOut, err := json.Marshal(transformer.FromUserEntityToDef(out))
+// VerifyEmail godoc
+//
+// @Summary      Verify a user email
+// @Description  Redeem the one-time token sent by email to verify the account email
+// @Tags         user
+// @Produce      json
+// @Success      200  {object}  definition.User
+// @Router       /user/verify [post]
+//
+// @Param        token  body  definition.VerifyInput  true  "verification token"
+func (h Handler) VerifyEmail(r Request[definition.VerifyInput]) (definition.User, error) {
+	out, err := h.userConn.VerifyEmail(r.Context(), r.Input.Token)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if _, err := w.Write(jsonOut); err != nil {
-		WriteError(w, err)
+		return definition.User{}, err
 	}
+	return transformer.FromUserEntityToDef(out), nil
 }
 
 // Login godoc
 //
 // @Summary      Authenticate a user
-// @Description  Perform the authentication/login of a user
+// @Description  Perform the authentication/login of a user. When two factor authentication is enabled the returned token is a challenge to exchange on /login/2fa
 // @Tags         login
 // @Produce      json
 // @Success      200  {object}  definition.Token
 // @Router       /user [post]
 //
 // @Param        user  body  definition.LoginInput  true  "credentials to authenticate user"
-func (h Handler) Login(w http.ResponseWriter, r *http.Request) {
-	defer r.Body.Close()
-
-	b, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		WriteError(w, errors.New("failed to read request body"))
-		return
-	}
-
-	var login definition.LoginInput
-	if err = json.Unmarshal(b, &login); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	if err := h.validator.Struct(login); err != nil {
-		h.log.Error(err)
-		w.WriteHeader(http.StatusBadRequest)
-		WriteError(w, err)
-		return
-	}
-
-	out, err := h.userConn.Login(r.Context(), login.Email, login.Password)
+func (h Handler) Login(r Request[definition.LoginInput]) (definition.Token, error) {
+	out, err := h.userConn.Login(r.Context(), r.Input.Email, r.Input.Password)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		WriteError(w, err)
-		return
-	}
-
-	jsonOut, err := json.Marshal(transformer.FromTokenEntityToDef(out))
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return definition.Token{}, err
 	}
+	return transformer.FromTokenEntityToDef(out), nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if _, err := w.Write(jsonOut); err != nil {
-		WriteError(w, err)
-	}
+// UnlockLogin godoc
+//
+// @Summary      Unlock a locked account
+// @Description  Lift the lock placed on an account after too many failed logins, using the token received by email
+// @Tags         login
+// @Success      204
+// @Router       /login/unlock [post]
+//
+// @Param        token  body  definition.UnlockInput  true  "unlock token"
+func (h Handler) UnlockLogin(r Request[definition.UnlockInput]) (NoContent, error) {
+	return NoContent{}, h.userConn.UnlockLogin(r.Context(), r.Input.Token)
 }
 
 // Swipe godoc
@@ -170,59 +125,41 @@ func (h Handler) Login(w http.ResponseWriter, r *http.Request) {
 // @Router       /swipe [post]
 //
 // @Param        user  body  definition.SwipeInput  true  "swipe data"
-func (h Handler) Swipe(w http.ResponseWriter, r *http.Request) {
-	userID, err := middleware.GetUserIDFromContext(r.Context())
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	defer r.Body.Close()
-
-	b, err := io.ReadAll(r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		WriteError(w, errors.New("failed to read request body"))
-		return
-	}
-
-	var swipe definition.SwipeInput
-	if err = json.Unmarshal(b, &swipe); err != nil {
-		h.log.Error(err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	if err := h.validator.Struct(swipe); err != nil {
-		h.log.Error(err)
-		w.WriteHeader(http.StatusBadRequest)
-		WriteError(w, err)
-		return
-	}
-
+func (h Handler) Swipe(r Request[definition.SwipeInput]) (definition.Match, error) {
 	var action bool
-	if swipe.Preference == "yes" {
+	if r.Input.Preference == "yes" {
 		action = true
 	}
 
-	out, err := h.userConn.Swipe(r.Context(), userID, swipe.UserID, action)
+	out, err := h.userConn.Swipe(r.Context(), r.UserID, r.Input.UserID, action)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		h.log.Error(err)
-		WriteError(w, err)
-		return
+		return definition.Match{}, err
 	}
+	return transformer.FromMatchEntityToDef(out), nil
+}
 
-	jsonOut, err := json.Marshal(transformer.FromMatchEntityToDef(out))
+// UpdateUserRole godoc
+//
+// @Summary      Update a user role
+// @Description  Change the role of a user, restricted to admins
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  definition.User
+// @Router       /admin/user/{id}/role [put]
+//
+// @Param        id    path  int                   true  "user id"
+// @Param        role  body  definition.RoleInput  true  "role to assign"
+func (h Handler) UpdateUserRole(r Request[definition.RoleInput]) (definition.User, error) {
+	userID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return definition.User{}, errInvalidParam.WithDetail("id must be a number")
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if _, err := w.Write(jsonOut); err != nil {
-		WriteError(w, err)
+	out, err := h.userConn.UpdateUserRole(r.Context(), r.UserID, userID, r.Input.Role)
+	if err != nil {
+		return definition.User{}, err
 	}
+	return transformer.FromUserEntityToDef(out), nil
 }
 
 // Discover godoc
@@ -236,35 +173,14 @@ func (h Handler) Swipe(w http.ResponseWriter, r *http.Request) {
 // @Param        max_age  query    int     false  "minimum profile age"
 // @Param        gender   query    string  false  "M or F"
 // @Router       /discover [get]
-func (h Handler) Discover(w http.ResponseWriter, r *http.Request) {
-	userID, err := middleware.GetUserIDFromContext(r.Context())
-	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
-
-	params := h.getDiscoverParams(r)
+func (h Handler) Discover(r Request[NoBody]) ([]definition.Discovery, error) {
+	params := h.getDiscoverParams(r.Request)
 
-	out, err := h.userConn.Discover(r.Context(), userID, params.Age, params.Gender)
+	out, err := h.userConn.Discover(r.Context(), r.UserID, params.Age, params.Gender)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		h.log.Error(err)
-		WriteError(w, err)
-		return
-	}
-
-	jsonOut, err := json.Marshal(
-		slice.Map(out, transformer.FromDiscoveryEntityToDef),
-	)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if _, err := w.Write(jsonOut); err != nil {
-		WriteError(w, err)
+		return nil, err
 	}
+	return slice.Map(out, transformer.FromDiscoveryEntityToDef), nil
 }
 
 func (h Handler) getDiscoverParams(r *http.Request) discoverParams {
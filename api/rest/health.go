@@ -0,0 +1,50 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/muzz/api/pkg/health"
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/rest/transformer"
+)
+
+// Livez godoc
+//
+// @Summary      Check the service is alive
+// @Description  Succeeds as long as the service serves requests, meant for liveness probes
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  definition.HealthReport
+// @Router       /livez [get]
+func (h Handler) Livez(w http.ResponseWriter, r *http.Request) {
+	h.encode(w, r, http.StatusOK, definition.HealthReport{Status: health.StatusOK})
+}
+
+// Readyz godoc
+//
+// @Summary      Check the service is ready
+// @Description  Check the database, the cache and the database migrations, answering 503 when a check fails or the service is shutting down. Meant for readiness probes and load balancers
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  definition.HealthReport
+// @Router       /readyz [get]
+// @Router       /healthz [get]
+func (h Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	if !h.readiness.Ready() {
+		h.encode(w, r, http.StatusServiceUnavailable, definition.HealthReport{
+			Status: health.StatusFail,
+			Checks: map[string]definition.HealthCheck{
+				"shutdown": {Status: health.StatusFail, Error: "shutting down"},
+			},
+		})
+		return
+	}
+
+	report := h.checker.Run(r.Context())
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	h.encode(w, r, status, transformer.FromHealthReportToDef(report))
+}
@@ -1,16 +1,29 @@
 package rest
 
 import (
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/muzz/api/rest/problem"
 )
 
-func WriteError(w http.ResponseWriter, err error) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(fmt.Sprintf(`{"error": "%s"}`, err.Error())))
+// WriteError responds with an application/problem+json document describing
+// err, see problem.New.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	problem.Write(w, r, err)
+}
+
+// NewValidator returns the validator of the request definitions.
+func NewValidator() *validator.Validate {
+	v := validator.New(
+		validator.WithRequiredStructEnabled(),
+	)
+
+	v.RegisterTagNameFunc(problem.JSONFieldName)
+	_ = v.RegisterValidation("dob", DOBValidator)
+
+	return v
 }
 
 func DOBValidator(fl validator.FieldLevel) bool {
@@ -0,0 +1,39 @@
+package rest
+
+import (
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/rest/transformer"
+)
+
+// RequestMagicLink godoc
+//
+// @Summary      Request a magic login link
+// @Description  Email a single-use passwordless login link, if the account exists. The link can only be redeemed from the same device, identified by its user agent and X-Device-ID header
+// @Tags         login
+// @Success      202
+// @Router       /login/magic [post]
+//
+// @Param        X-Device-ID  header  string                     false  "opaque device identifier"
+// @Param        email        body    definition.MagicLinkInput  true   "account email"
+func (h Handler) RequestMagicLink(r Request[definition.MagicLinkInput]) (NoContent, error) {
+	return NoContent{}, h.userConn.RequestMagicLink(r.Context(), r.Input.Email)
+}
+
+// LoginMagicLink godoc
+//
+// @Summary      Log in with a magic link
+// @Description  Exchange a magic link token for a session token, or for a challenge token when two factor authentication is enabled
+// @Tags         login
+// @Produce      json
+// @Success      200  {object}  definition.Token
+// @Router       /login/magic/redeem [post]
+//
+// @Param        X-Device-ID  header  string                           false  "opaque device identifier"
+// @Param        token        body    definition.MagicLinkRedeemInput  true   "magic link token"
+func (h Handler) LoginMagicLink(r Request[definition.MagicLinkRedeemInput]) (definition.Token, error) {
+	out, err := h.userConn.LoginMagicLink(r.Context(), r.Input.Token)
+	if err != nil {
+		return definition.Token{}, err
+	}
+	return transformer.FromTokenEntityToDef(out), nil
+}
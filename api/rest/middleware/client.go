@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/muzz/api/pkg/client"
+)
+
+const maxDeviceName = 64
+
+type ClientMiddleware interface {
+	Handle(next http.Handler) http.Handler
+}
+
+// ClientHandler stores the client ip, user agent and device details in the
+// request context. The device id is an opaque value the apps send in
+// X-Device-ID, along with a human readable X-Device-Name.
+// Proxy headers are only honoured when the api runs behind a trusted proxy,
+// otherwise any client could spoof its address.
+type ClientHandler struct {
+	trustProxy bool
+}
+
+func NewClientHandler(trustProxy bool) ClientHandler {
+	return ClientHandler{
+		trustProxy: trustProxy,
+	}
+}
+
+func (m ClientHandler) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := client.NewContext(r.Context(), client.Info{
+			IP:         m.clientIP(r),
+			UserAgent:  r.UserAgent(),
+			DeviceID:   r.Header.Get("X-Device-ID"),
+			DeviceName: truncate(r.Header.Get("X-Device-Name"), maxDeviceName),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (m ClientHandler) clientIP(r *http.Request) string {
+	if m.trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			ip, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(ip)
+		}
+		if ip := r.Header.Get("X-Real-IP"); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func truncate(value string, n int) string {
+	runes := []rune(value)
+	if len(runes) <= n {
+		return value
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/muzz/api/pkg/i18n"
+)
+
+type LocaleMiddleware interface {
+	Handle(next http.Handler) http.Handler
+}
+
+// LocaleHandler negotiates the language of the request from its
+// Accept-Language header, the translator it picks is used to write error
+// messages and is advertised in the Content-Language of the response.
+type LocaleHandler struct {
+	translator *i18n.Translator
+}
+
+func NewLocaleHandler(translator *i18n.Translator) LocaleHandler {
+	return LocaleHandler{
+		translator: translator,
+	}
+}
+
+func (m LocaleHandler) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trans := m.translator.Negotiate(r.Header.Get("Accept-Language"))
+
+		w.Header().Set("Content-Language", i18n.Tag(trans))
+		next.ServeHTTP(w, r.WithContext(i18n.NewContext(r.Context(), trans)))
+	})
+}
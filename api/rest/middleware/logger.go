@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/muzz/api/pkg/logger"
+	"github.com/muzz/api/pkg/requestid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type accessKey struct{}
+
+// access holds what the access log learns about a request while it is
+// served by inner middlewares, e.g. the authenticated user.
+type access struct {
+	userID int
+}
+
+type LoggerMiddleware interface {
+	Handle(next http.Handler) http.Handler
+}
+
+// LoggerHandler carries a logger in the context of every request, tagged
+// with its request id and the route pattern it matched, and writes an access
+// log line once the request is served. The authentication middleware later
+// adds the user id.
+type LoggerHandler struct {
+	l      *logrus.Logger
+	router *http.ServeMux
+}
+
+func NewLoggerHandler(l *logrus.Logger, router *http.ServeMux) LoggerHandler {
+	return LoggerHandler{
+		l:      l,
+		router: router,
+	}
+}
+
+func (m LoggerHandler) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, route := m.router.Handler(r)
+
+		info := &access{}
+		ctx := context.WithValue(r.Context(), accessKey{}, info)
+		fields := logrus.Fields{
+			"request_id": requestid.FromContext(r.Context()),
+			"route":      route,
+		}
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			fields["trace_id"] = sc.TraceID().String()
+		}
+		ctx = logger.WithFields(ctx, m.l, fields)
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		entry := logger.FromContext(ctx, m.l).WithFields(logrus.Fields{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     rec.status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"bytes":      rec.bytes,
+		})
+		if info.userID != 0 {
+			entry = entry.WithField("user_id", info.userID)
+		}
+
+		if rec.status >= http.StatusInternalServerError {
+			entry.Error("request served")
+			return
+		}
+		entry.Info("request served")
+	})
+}
+
+// setAccessUserID reports the authenticated user to the access log.
+func setAccessUserID(ctx context.Context, userID int) {
+	if info, ok := ctx.Value(accessKey{}).(*access); ok {
+		info.userID = userID
+	}
+}
+
+// recorder captures the status and size of a response.
+type recorder struct {
+	http.ResponseWriter
+	status  int
+	bytes   int
+	written bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.written {
+		r.status = status
+		r.written = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.written = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
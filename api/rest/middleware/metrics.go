@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/muzz/api/pkg/metrics"
+)
+
+type MetricsMiddleware interface {
+	Handle(next http.Handler) http.Handler
+}
+
+// MetricsHandler records the latency of every request by the route pattern
+// it matched, rather than its path, to keep the number of series bounded.
+type MetricsHandler struct {
+	metrics *metrics.Metrics
+	router  *http.ServeMux
+}
+
+func NewMetricsHandler(metrics *metrics.Metrics, router *http.ServeMux) MetricsHandler {
+	return MetricsHandler{
+		metrics: metrics,
+		router:  router,
+	}
+}
+
+func (m MetricsHandler) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, route := m.router.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		m.metrics.ObserveRequest(route, rec.status, time.Since(start))
+	})
+}
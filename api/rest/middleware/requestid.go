@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/muzz/api/pkg/requestid"
+)
+
+type RequestIDMiddleware interface {
+	Handle(next http.Handler) http.Handler
+}
+
+// RequestIDHandler tags every request with an id, reusing the X-Request-ID
+// sent by the client or a proxy when there is one. The id is echoed in the
+// response so that failures reported by users can be found in the logs.
+type RequestIDHandler struct{}
+
+func NewRequestIDHandler() RequestIDHandler {
+	return RequestIDHandler{}
+}
+
+func (m RequestIDHandler) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
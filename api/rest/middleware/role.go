@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/rest/problem"
+)
+
+var errForbidden = errs.New(errs.Forbidden, "forbidden", "insufficient role")
+
+type RoleMiddleware interface {
+	Handle(next http.Handler, roles ...string) http.Handler
+}
+
+// RoleHandler only lets requests through when the authenticated user holds
+// one of the required roles. It relies on AuthHandler having populated the
+// request context, so it must always be wrapped by it.
+type RoleHandler struct{}
+
+func NewRoleHandler() RoleHandler {
+	return RoleHandler{}
+}
+
+func (m RoleHandler) Handle(next http.Handler, roles ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, err := GetRoleFromContext(r.Context())
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		if !slices.Contains(roles, role) {
+			problem.Write(w, r, errForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
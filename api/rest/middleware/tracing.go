@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "github.com/muzz/api/rest"
+
+type TracingMiddleware interface {
+	Handle(next http.Handler) http.Handler
+}
+
+// TracingHandler starts a server span for every request, continuing the
+// trace of the caller when it sends a W3C traceparent header. Spans are named
+// after the route pattern the request matched.
+type TracingHandler struct {
+	tracer trace.Tracer
+	router *http.ServeMux
+}
+
+func NewTracingHandler(provider trace.TracerProvider, router *http.ServeMux) TracingHandler {
+	return TracingHandler{
+		tracer: provider.Tracer(instrumentation),
+		router: router,
+	}
+}
+
+func (m TracingHandler) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := m.router.Handler(r)
+
+		name := route
+		if name == "" {
+			name = r.Method
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := m.tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(
+			semconv.HTTPResponseStatusCode(rec.status),
+			attribute.Int("http.response.body.size", rec.bytes),
+		)
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
@@ -2,22 +2,36 @@ package middleware
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/logger"
+	"github.com/muzz/api/rest/problem"
 	"github.com/muzz/api/service"
+	"github.com/sirupsen/logrus"
 )
 
 type contextKey string
 
-const userIDKey = contextKey("userID")
+const (
+	userIDKey    = contextKey("userID")
+	roleKey      = contextKey("role")
+	sessionIDKey = contextKey("sessionID")
+)
+
+var (
+	ErrUnauthenticated   = errs.New(errs.Unauthorized, "unauthenticated", "authentication required")
+	errInvalidAuthHeader = errs.New(errs.Unauthorized, "invalid_authorization", "authorization header must hold a bearer token")
+	errInvalidToken      = errs.New(errs.Unauthorized, "invalid_access_token", "invalid or expired access token")
+)
 
 type TokenClaims struct {
-	UserID     int   `json:"user_id"`
-	Authorized bool  `json:"authorized"`
-	Expires    int64 `json:"expires"`
+	UserID     int    `json:"user_id"`
+	SessionID  string `json:"session_id"`
+	Authorized bool   `json:"authorized"`
+	Role       string `json:"role"`
+	Expires    int64  `json:"expires"`
 }
 
 type AuthMiddleware interface {
@@ -25,11 +39,13 @@ type AuthMiddleware interface {
 }
 
 type AuthHandler struct {
+	l           *logrus.Logger
 	authService service.AuthConnector
 }
 
-func NewAuthHandler(authService service.AuthConnector) AuthHandler {
+func NewAuthHandler(l *logrus.Logger, authService service.AuthConnector) AuthHandler {
 	return AuthHandler{
+		l:           l,
 		authService: authService,
 	}
 }
@@ -38,13 +54,13 @@ func (m AuthHandler) Handle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "authorization header missing", http.StatusUnauthorized)
+			problem.Write(w, r, ErrUnauthenticated.WithDetail("authorization header missing"))
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			http.Error(w, "invalid token format", http.StatusUnauthorized)
+			problem.Write(w, r, errInvalidAuthHeader)
 			return
 		}
 
@@ -52,13 +68,29 @@ func (m AuthHandler) Handle(next http.Handler) http.Handler {
 
 		var claims TokenClaims
 		if err := m.authService.GetTokenClaims(ctx, tokenString, &claims); err != nil {
-			http.Error(w, "failed to retrieve token claims", http.StatusUnauthorized)
+			problem.Write(w, r, errInvalidToken)
 			return
 		}
 
-		fmt.Println(claims.UserID)
+		// only session tokens are authorized, other signed tokens (e.g. email
+		// verification) must not grant access
+		if !claims.Authorized {
+			problem.Write(w, r, errInvalidToken)
+			return
+		}
+
+		// tokens of deleted sessions are rejected before they expire, with
+		// service.ErrSessionRevoked
+		if err := m.authService.TouchSession(ctx, claims.SessionID, claims.Expires); err != nil {
+			problem.Write(w, r, err)
+			return
+		}
 
+		ctx = logger.WithFields(ctx, m.l, logrus.Fields{"user_id": claims.UserID})
+		setAccessUserID(ctx, claims.UserID)
 		ctx = context.WithValue(ctx, userIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, roleKey, claims.Role)
+		ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -66,7 +98,23 @@ func (m AuthHandler) Handle(next http.Handler) http.Handler {
 func GetUserIDFromContext(ctx context.Context) (int, error) {
 	userID, ok := ctx.Value(userIDKey).(int)
 	if !ok {
-		return 0, errors.New("user id not found")
+		return 0, ErrUnauthenticated
 	}
 	return userID, nil
 }
+
+func GetRoleFromContext(ctx context.Context) (string, error) {
+	role, ok := ctx.Value(roleKey).(string)
+	if !ok || role == "" {
+		return "", ErrUnauthenticated
+	}
+	return role, nil
+}
+
+func GetSessionIDFromContext(ctx context.Context) (string, error) {
+	sessionID, ok := ctx.Value(sessionIDKey).(string)
+	if !ok || sessionID == "" {
+		return "", ErrUnauthenticated
+	}
+	return sessionID, nil
+}
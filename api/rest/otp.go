@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/rest/transformer"
+)
+
+// RequestOTP godoc
+//
+// @Summary      Request a one-time login code
+// @Description  Text a one-time login code to the phone number, if an account uses it
+// @Tags         login
+// @Success      202
+// @Router       /login/otp [post]
+//
+// @Param        phone  body  definition.OTPInput  true  "phone number in E.164 format"
+func (h Handler) RequestOTP(r Request[definition.OTPInput]) (NoContent, error) {
+	return NoContent{}, h.userConn.RequestOTP(r.Context(), r.Input.Phone)
+}
+
+// LoginOTP godoc
+//
+// @Summary      Log in with a one-time code
+// @Description  Exchange the code texted by /login/otp for a session token, or for a challenge token when two factor authentication is enabled
+// @Tags         login
+// @Produce      json
+// @Success      200  {object}  definition.Token
+// @Router       /login/otp/verify [post]
+//
+// @Param        login  body  definition.OTPLoginInput  true  "phone number and code"
+func (h Handler) LoginOTP(r Request[definition.OTPLoginInput]) (definition.Token, error) {
+	out, err := h.userConn.LoginOTP(r.Context(), r.Input.Phone, r.Input.Code)
+	if err != nil {
+		return definition.Token{}, err
+	}
+	return transformer.FromTokenEntityToDef(out), nil
+}
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/rest/transformer"
+)
+
+// BeginPasskeyRegistration godoc
+//
+// @Summary      Start a passkey registration
+// @Description  Return the options for navigator.credentials.create() and the ceremony session id
+// @Tags         passkey
+// @Produce      json
+// @Success      200  {object}  definition.PasskeyCeremony
+// @Router       /passkey/register/begin [post]
+func (h Handler) BeginPasskeyRegistration(r Request[NoBody]) (definition.PasskeyCeremony, error) {
+	out, err := h.userConn.BeginPasskeyRegistration(r.Context(), r.UserID)
+	if err != nil {
+		return definition.PasskeyCeremony{}, err
+	}
+	return transformer.FromPasskeyCeremonyEntityToDef(out), nil
+}
+
+// FinishPasskeyRegistration godoc
+//
+// @Summary      Finish a passkey registration
+// @Description  Verify the attestation returned by the authenticator and store the passkey
+// @Tags         passkey
+// @Success      204
+// @Router       /passkey/register/finish [post]
+//
+// @Param        credential  body  definition.PasskeyFinishInput  true  "ceremony session id and credential"
+func (h Handler) FinishPasskeyRegistration(r Request[definition.PasskeyFinishInput]) (NoContent, error) {
+	return NoContent{}, h.userConn.FinishPasskeyRegistration(r.Context(), r.UserID, r.Input.SessionID, r.Input.Credential)
+}
+
+// BeginPasskeyLogin godoc
+//
+// @Summary      Start a passkey login
+// @Description  Return the options for navigator.credentials.get() and the ceremony session id
+// @Tags         passkey
+// @Produce      json
+// @Success      200  {object}  definition.PasskeyCeremony
+// @Router       /passkey/login/begin [post]
+func (h Handler) BeginPasskeyLogin(r Request[NoBody]) (definition.PasskeyCeremony, error) {
+	out, err := h.userConn.BeginPasskeyLogin(r.Context())
+	if err != nil {
+		return definition.PasskeyCeremony{}, err
+	}
+	return transformer.FromPasskeyCeremonyEntityToDef(out), nil
+}
+
+// FinishPasskeyLogin godoc
+//
+// @Summary      Finish a passkey login
+// @Description  Verify the assertion returned by the authenticator and authenticate its owner
+// @Tags         passkey
+// @Produce      json
+// @Success      200  {object}  definition.Token
+// @Router       /passkey/login/finish [post]
+//
+// @Param        credential  body  definition.PasskeyFinishInput  true  "ceremony session id and credential"
+func (h Handler) FinishPasskeyLogin(r Request[definition.PasskeyFinishInput]) (definition.Token, error) {
+	out, err := h.userConn.FinishPasskeyLogin(r.Context(), r.Input.SessionID, r.Input.Credential)
+	if err != nil {
+		return definition.Token{}, err
+	}
+	return transformer.FromTokenEntityToDef(out), nil
+}
@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"github.com/muzz/api/rest/definition"
+)
+
+// ForgotPassword godoc
+//
+// @Summary      Request a password reset
+// @Description  Email a single-use password reset link to the account owner, if the account exists
+// @Tags         password
+// @Success      202
+// @Router       /password/forgot [post]
+//
+// @Param        email  body  definition.ForgotPasswordInput  true  "account email"
+func (h Handler) ForgotPassword(r Request[definition.ForgotPasswordInput]) (NoContent, error) {
+	return NoContent{}, h.userConn.ForgotPassword(r.Context(), r.Input.Email)
+}
+
+// ResetPassword godoc
+//
+// @Summary      Reset a password
+// @Description  Set a new password using a reset token, revoking all existing sessions
+// @Tags         password
+// @Success      204
+// @Router       /password/reset [post]
+//
+// @Param        reset  body  definition.ResetPasswordInput  true  "reset token and new password"
+func (h Handler) ResetPassword(r Request[definition.ResetPasswordInput]) (NoContent, error) {
+	return NoContent{}, h.userConn.ResetPassword(r.Context(), r.Input.Token, r.Input.Password)
+}
+
+// ChangePassword godoc
+//
+// @Summary      Change the password
+// @Description  Change the password of the authenticated user, revoking all existing sessions
+// @Tags         password
+// @Success      204
+// @Router       /password/change [post]
+//
+// @Param        change  body  definition.ChangePasswordInput  true  "old and new password"
+func (h Handler) ChangePassword(r Request[definition.ChangePasswordInput]) (NoContent, error) {
+	return NoContent{}, h.userConn.ChangePassword(r.Context(), r.UserID, r.Input.OldPassword, r.Input.NewPassword)
+}
@@ -0,0 +1,48 @@
+package problem
+
+import (
+	"net/http"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/muzz/api/pkg/i18n"
+)
+
+// localized translates problem messages with the translator negotiated for
+// a request, messages are left in english when there is none.
+type localized struct {
+	trans ut.Translator
+}
+
+func localizer(r *http.Request) localized {
+	trans, _ := i18n.FromContext(r.Context())
+	return localized{trans: trans}
+}
+
+func (l localized) message(code, fallback string) string {
+	if l.trans == nil {
+		return fallback
+	}
+	return i18n.Message(l.trans, code, fallback)
+}
+
+// detail returns the detail of a domain error, which is only written in
+// english and would otherwise end up mixed with a translated message.
+func (l localized) detail(detail string) string {
+	if l.trans != nil && l.trans.Locale() != "en" {
+		return ""
+	}
+	return detail
+}
+
+// field describes a failed validation rule, the validator reports the raw
+// error as the translation of rules it has no message for.
+func (l localized) field(fe validator.FieldError) string {
+	if l.trans == nil {
+		return describe(fe)
+	}
+	if text := fe.Translate(l.trans); text != fe.Error() {
+		return text
+	}
+	return describe(fe)
+}
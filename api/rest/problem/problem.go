@@ -0,0 +1,117 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/requestid"
+)
+
+const (
+	ContentType = "application/problem+json"
+
+	// typePrefix namespaces problem types, the stable error code is appended
+	typePrefix = "urn:muzz:problem:"
+
+	codeInternal         = "internal_error"
+	codeValidationFailed = "validation_failed"
+)
+
+var statuses = map[errs.Kind]int{
+	errs.NotFound:     http.StatusNotFound,
+	errs.Conflict:     http.StatusConflict,
+	errs.Unauthorized: http.StatusUnauthorized,
+	errs.Forbidden:    http.StatusForbidden,
+	errs.Validation:   http.StatusBadRequest,
+	errs.RateLimited:  http.StatusTooManyRequests,
+
+	errs.TooLarge:             http.StatusRequestEntityTooLarge,
+	errs.UnsupportedMediaType: http.StatusUnsupportedMediaType,
+}
+
+// Problem is an RFC 7807 problem details document. Code repeats the last
+// segment of Type for clients that would rather not parse it.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a request field that failed validation, Field being
+// its json name and Rule the validation tag it failed.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// New describes err as a problem. Validation errors list the failed fields,
+// domain errors are reported with the status matching their kind and any
+// other error as an internal error, without leaking its message. Messages
+// are translated to the locale negotiated for r when there is one, their
+// english details being dropped from the translations.
+func New(r *http.Request, err error) Problem {
+	l := localizer(r)
+
+	p := Problem{
+		Status:    http.StatusInternalServerError,
+		Code:      codeInternal,
+		Detail:    l.message(codeInternal, "an unexpected error occurred"),
+		Instance:  r.URL.Path,
+		RequestID: requestid.FromContext(r.Context()),
+	}
+
+	var validationErrs validator.ValidationErrors
+	if e, ok := errs.As(err); ok && e.Kind != errs.Internal {
+		p.Status = statuses[e.Kind]
+		p.Code = e.Code
+		p.Detail = l.message(e.Code, e.Message)
+		if detail := l.detail(e.Detail); detail != "" {
+			p.Detail += ": " + detail
+		}
+	} else if errors.As(err, &validationErrs) {
+		p.Status = http.StatusBadRequest
+		p.Code = codeValidationFailed
+		p.Detail = l.message(codeValidationFailed, "the request contains invalid fields")
+		for _, fe := range validationErrs {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Message: l.field(fe),
+			})
+		}
+	}
+
+	p.Type = typePrefix + p.Code
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// Write responds to r with the problem describing err.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := New(r, err)
+
+	if e, ok := errs.As(err); ok && e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
@@ -0,0 +1,44 @@
+package problem
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// JSONFieldName makes the validator report fields by their json name, to be
+// registered with validator.RegisterTagNameFunc.
+func JSONFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// describe turns a failed validation rule into a readable message.
+func describe(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required when " + strings.ToLower(fe.Param()) + " is missing"
+	case "email":
+		return "must be a valid email address"
+	case "e164":
+		return "must be a phone number in E.164 format, e.g. +447700900123"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "len":
+		return "must be " + fe.Param() + " characters long"
+	case "numeric":
+		return "must only contain digits"
+	case "dob":
+		return "must be a date formatted as YYYY-MM-DD"
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
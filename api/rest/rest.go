@@ -5,6 +5,7 @@ import (
 
 	_ "github.com/muzz/api/docs" // docs is generated by Swag CLI, you have to import it.
 	"github.com/muzz/api/rest/middleware"
+	"github.com/muzz/api/service/entity"
 	"github.com/sirupsen/logrus"
 	httpSwagger "github.com/swaggo/http-swagger" // http-swagger middleware
 )
@@ -13,25 +14,85 @@ import (
 // @version      1.0
 // @description  This is a API representing a simple dating api system.
 // @host         localhost:3000
-func NewRest(log *logrus.Logger, router *http.ServeMux, r Handler, auth middleware.AuthMiddleware) error {
+func NewRest(
+	log *logrus.Logger,
+	router *http.ServeMux,
+	r Handler,
+	auth middleware.AuthMiddleware,
+	role middleware.RoleMiddleware,
+) error {
 
-	router.HandleFunc("GET /healthz", r.Health)
+	router.HandleFunc("GET /livez", r.Livez)
+	router.HandleFunc("GET /readyz", r.Readyz)
+	// kept for the probes relying on it
+	router.HandleFunc("GET /healthz", r.Readyz)
 	router.Handle("GET /swagger/*", httpSwagger.Handler())
 
 	// user
-	router.HandleFunc("POST /user/create", r.CreateUser)
+	router.HandleFunc("POST /user/create", Handle(r, r.CreateUser))
+	router.HandleFunc("POST /user/verify", Handle(r, r.VerifyEmail))
 
 	// login
-	router.HandleFunc("POST /login", r.Login)
+	router.HandleFunc("POST /login", Handle(r, r.Login))
+	router.HandleFunc("POST /login/unlock", Handle(r, r.UnlockLogin))
+	router.HandleFunc("POST /login/2fa", Handle(r, r.LoginTwoFactor))
+	router.HandleFunc("POST /login/magic", Handle(r, r.RequestMagicLink, WithStatus(http.StatusAccepted)))
+	router.HandleFunc("POST /login/magic/redeem", Handle(r, r.LoginMagicLink))
+	router.HandleFunc("POST /login/otp", Handle(r, r.RequestOTP, WithStatus(http.StatusAccepted)))
+	router.HandleFunc("POST /login/otp/verify", Handle(r, r.LoginOTP))
+
+	// passkey
+	router.Handle("POST /passkey/register/begin", auth.Handle(
+		Handle(r, r.BeginPasskeyRegistration, Authenticated())),
+	)
+	router.Handle("POST /passkey/register/finish", auth.Handle(
+		Handle(r, r.FinishPasskeyRegistration, Authenticated())),
+	)
+	router.HandleFunc("POST /passkey/login/begin", Handle(r, r.BeginPasskeyLogin))
+	router.HandleFunc("POST /passkey/login/finish", Handle(r, r.FinishPasskeyLogin))
+
+	// two factor
+	router.Handle("POST /2fa/enroll", auth.Handle(
+		Handle(r, r.EnrollTwoFactor, Authenticated())),
+	)
+	router.Handle("POST /2fa/confirm", auth.Handle(
+		Handle(r, r.ConfirmTwoFactor, Authenticated())),
+	)
+	router.Handle("POST /2fa/disable", auth.Handle(
+		Handle(r, r.DisableTwoFactor, Authenticated())),
+	)
+
+	// password
+	router.HandleFunc("POST /password/forgot", Handle(r, r.ForgotPassword, WithStatus(http.StatusAccepted)))
+	router.HandleFunc("POST /password/reset", Handle(r, r.ResetPassword))
+	router.Handle("POST /password/change", auth.Handle(
+		Handle(r, r.ChangePassword, Authenticated())),
+	)
+
+	// sessions
+	router.Handle("GET /sessions", auth.Handle(
+		Handle(r, r.GetSessions, Authenticated())),
+	)
+	router.Handle("DELETE /sessions/{id}", auth.Handle(
+		Handle(r, r.DeleteSession, Authenticated())),
+	)
 
 	// swipe
 	router.Handle("POST /swipe", auth.Handle(
-		http.HandlerFunc(r.Swipe)),
+		Handle(r, r.Swipe, Authenticated())),
 	)
 
 	//discover
 	router.Handle("GET /discover", auth.Handle(
-		http.HandlerFunc(r.Discover)),
+		Handle(r, r.Discover, Authenticated())),
+	)
+
+	// admin
+	router.Handle("PUT /admin/user/{id}/role", auth.Handle(
+		role.Handle(Handle(r, r.UpdateUserRole, Authenticated()), entity.RoleAdmin)),
+	)
+	router.Handle("GET /admin/audit", auth.Handle(
+		role.Handle(Handle(r, r.GetAuditEvents, Authenticated()), entity.RoleAdmin)),
 	)
 	return nil
 }
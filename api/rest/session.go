@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/rest/middleware"
+	"github.com/muzz/api/rest/transformer"
+)
+
+// GetSessions godoc
+//
+// @Summary      List sessions
+// @Description  List the active sessions of the authenticated user, most recently used first
+// @Tags         sessions
+// @Produce      json
+// @Success      200  {array}  definition.Session
+// @Router       /sessions [get]
+func (h Handler) GetSessions(r Request[NoBody]) ([]definition.Session, error) {
+	sessionID, _ := middleware.GetSessionIDFromContext(r.Context())
+
+	out, err := h.userConn.GetSessions(r.Context(), r.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]definition.Session, 0, len(out))
+	for _, session := range out {
+		def := transformer.FromSessionEntityToDef(session)
+		def.Current = def.ID == sessionID
+		sessions = append(sessions, def)
+	}
+	return sessions, nil
+}
+
+// DeleteSession godoc
+//
+// @Summary      Delete a session
+// @Description  Log the authenticated user out of one of its sessions, the token of that session stops working right away
+// @Tags         sessions
+// @Success      204
+// @Router       /sessions/{id} [delete]
+//
+// @Param        id  path  string  true  "session id"
+func (h Handler) DeleteSession(r Request[NoBody]) (NoContent, error) {
+	return NoContent{}, h.userConn.DeleteSession(r.Context(), r.UserID, r.PathValue("id"))
+}
@@ -0,0 +1,19 @@
+package transformer
+
+import (
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/service/entity"
+)
+
+func FromAuditEventEntityToDef(in entity.AuditEvent) definition.AuditEvent {
+	return definition.AuditEvent{
+		ID:        in.ID,
+		Type:      in.Type,
+		ActorID:   in.ActorID,
+		UserID:    in.UserID,
+		IP:        in.IP,
+		UserAgent: in.UserAgent,
+		Details:   in.Details,
+		CreatedAt: in.CreatedAt,
+	}
+}
@@ -0,0 +1,23 @@
+package transformer
+
+import (
+	"github.com/muzz/api/pkg/health"
+	"github.com/muzz/api/rest/definition"
+)
+
+func FromHealthReportToDef(in health.Report) definition.HealthReport {
+	checks := make(map[string]definition.HealthCheck, len(in.Checks))
+	for name, result := range in.Checks {
+		checks[name] = definition.HealthCheck{
+			Status:    result.Status,
+			LatencyMs: float64(result.Latency.Microseconds()) / 1000,
+			Error:     result.Error,
+			Cached:    result.Cached,
+		}
+	}
+
+	return definition.HealthReport{
+		Status: in.Status,
+		Checks: checks,
+	}
+}
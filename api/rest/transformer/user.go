@@ -8,6 +8,7 @@ import (
 func FromUserInputDefToEntity(in definition.UserInput) entity.UserInput {
 	return entity.UserInput{
 		Email:        in.Email,
+		Phone:        in.Phone,
 		Password:     in.Password,
 		Name:         in.Name,
 		Gender:       in.Gender,
@@ -17,23 +18,52 @@ func FromUserInputDefToEntity(in definition.UserInput) entity.UserInput {
 	}
 }
 
+func FromSessionEntityToDef(in entity.Session) definition.Session {
+	return definition.Session{
+		ID:         in.ID,
+		DeviceName: in.DeviceName,
+		UserAgent:  in.UserAgent,
+		IP:         in.IP,
+		CreatedAt:  in.CreatedAt,
+		LastSeenAt: in.LastSeenAt,
+	}
+}
+
 func FromUserEntityToDef(in entity.User) definition.User {
 	return definition.User{
-		ID:           in.ID,
-		Email:        in.Email,
-		Password:     in.Password,
-		Name:         in.Name,
-		Gender:       in.Gender,
-		Age:          getAge(in.DOB),
-		LocationLat:  in.LocationLat,
-		LocationLong: in.LocationLong,
+		ID:            in.ID,
+		Email:         in.Email,
+		Phone:         in.Phone,
+		Name:          in.Name,
+		Gender:        in.Gender,
+		Age:           getAge(in.DOB),
+		LocationLat:   in.LocationLat,
+		LocationLong:  in.LocationLong,
+		Role:          in.Role,
+		EmailVerified: in.EmailVerified,
+		PhoneVerified: in.PhoneVerified,
 	}
 }
 
 func FromTokenEntityToDef(in entity.Token) definition.Token {
 	return definition.Token{
-		Token:   in.Token,
-		Expires: in.Expires,
+		Token:             in.Token,
+		Expires:           in.Expires,
+		TwoFactorRequired: in.TwoFactorRequired,
+	}
+}
+
+func FromPasskeyCeremonyEntityToDef(in entity.PasskeyCeremony) definition.PasskeyCeremony {
+	return definition.PasskeyCeremony{
+		SessionID: in.SessionID,
+		Options:   in.Options,
+	}
+}
+
+func FromTwoFactorEnrollmentEntityToDef(in entity.TwoFactorEnrollment) definition.TwoFactorEnrollment {
+	return definition.TwoFactorEnrollment{
+		Secret: in.Secret,
+		URI:    in.URI,
 	}
 }
 
@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"github.com/muzz/api/rest/definition"
+	"github.com/muzz/api/rest/transformer"
+)
+
+// LoginTwoFactor godoc
+//
+// @Summary      Complete a two factor login
+// @Description  Exchange the challenge returned by /login and a totp or recovery code for a session token
+// @Tags         login
+// @Produce      json
+// @Success      200  {object}  definition.Token
+// @Router       /login/2fa [post]
+//
+// @Param        login  body  definition.TwoFactorLoginInput  true  "challenge and code"
+func (h Handler) LoginTwoFactor(r Request[definition.TwoFactorLoginInput]) (definition.Token, error) {
+	out, err := h.userConn.LoginTwoFactor(r.Context(), r.Input.ChallengeToken, r.Input.Code)
+	if err != nil {
+		return definition.Token{}, err
+	}
+	return transformer.FromTokenEntityToDef(out), nil
+}
+
+// EnrollTwoFactor godoc
+//
+// @Summary      Start two factor enrolment
+// @Description  Generate a totp secret and otpauth uri for the authenticated user, to be confirmed on /2fa/confirm
+// @Tags         2fa
+// @Produce      json
+// @Success      200  {object}  definition.TwoFactorEnrollment
+// @Router       /2fa/enroll [post]
+func (h Handler) EnrollTwoFactor(r Request[NoBody]) (definition.TwoFactorEnrollment, error) {
+	out, err := h.userConn.EnrollTwoFactor(r.Context(), r.UserID)
+	if err != nil {
+		return definition.TwoFactorEnrollment{}, err
+	}
+	return transformer.FromTwoFactorEnrollmentEntityToDef(out), nil
+}
+
+// ConfirmTwoFactor godoc
+//
+// @Summary      Confirm two factor enrolment
+// @Description  Enable two factor authentication with a first totp code and return the one-time recovery codes
+// @Tags         2fa
+// @Produce      json
+// @Success      200  {object}  definition.RecoveryCodes
+// @Router       /2fa/confirm [post]
+//
+// @Param        code  body  definition.TwoFactorCodeInput  true  "totp code"
+func (h Handler) ConfirmTwoFactor(r Request[definition.TwoFactorCodeInput]) (definition.RecoveryCodes, error) {
+	out, err := h.userConn.ConfirmTwoFactor(r.Context(), r.UserID, r.Input.Code)
+	if err != nil {
+		return definition.RecoveryCodes{}, err
+	}
+	return definition.RecoveryCodes{RecoveryCodes: out}, nil
+}
+
+// DisableTwoFactor godoc
+//
+// @Summary      Disable two factor authentication
+// @Description  Disable two factor authentication with a totp or recovery code
+// @Tags         2fa
+// @Success      204
+// @Router       /2fa/disable [post]
+//
+// @Param        code  body  definition.TwoFactorCodeInput  true  "totp or recovery code"
+func (h Handler) DisableTwoFactor(r Request[definition.TwoFactorCodeInput]) (NoContent, error) {
+	return NoContent{}, h.userConn.DisableTwoFactor(r.Context(), r.UserID, r.Input.Code)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/muzz/api/pkg/password"
+	"github.com/muzz/api/pkg/pg"
+	"github.com/muzz/api/pkg/seed"
+	"github.com/sirupsen/logrus"
+)
+
+// seedUsers parses the seed command arguments and returns an invocable
+// function inserting a synthetic population of users, swipes and matches.
+// The same arguments always generate the same population.
+func seedUsers(args []string) (func(l *logrus.Logger, db *pg.Postgres, h password.Hasher) error, error) {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+
+	var settings seed.Settings
+	fs.IntVar(&settings.Users, "users", 1000, "number of users")
+	fs.Uint64Var(&settings.Seed, "seed", 1, "random seed")
+	cities := fs.String("cities", "london,birmingham,manchester,leeds,glasgow", "comma separated cities, by name or lat:long")
+	fs.Float64Var(&settings.Spread, "spread", 8, "standard deviation in km of the distance of the users to their city")
+	fs.Float64Var(&settings.FemaleRatio, "female", 0.5, "share of the users with the F gender")
+	fs.Float64Var(&settings.VerifiedRatio, "verified", 0.7, "share of the users with a verified email")
+	fs.IntVar(&settings.Swipes, "swipes", 20, "average number of swipes by user")
+	fs.Float64Var(&settings.LikeRatio, "like", 0.4, "chance of a swipe being a like")
+	fs.Float64Var(&settings.Reciprocity, "reciprocity", 0.3, "chance of a swiped user swiping back")
+	date := fs.String("date", time.Now().UTC().Format(time.DateOnly), "date ages and swipes are relative to (YYYY-MM-DD)")
+	pass := fs.String("password", "seeded-password", "password of every user")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if settings.Users <= 0 || settings.Swipes < 0 || settings.Spread < 0 {
+		return nil, errors.New("seed: -users must be positive, -swipes and -spread not negative")
+	}
+
+	for _, ratio := range []float64{settings.FemaleRatio, settings.VerifiedRatio, settings.LikeRatio, settings.Reciprocity} {
+		if ratio < 0 || ratio > 1 {
+			return nil, errors.New("seed: -female, -verified, -like and -reciprocity must be between 0 and 1")
+		}
+	}
+
+	var err error
+	if settings.Cities, err = seed.ParseCities(*cities); err != nil {
+		return nil, err
+	}
+
+	if settings.Now, err = time.Parse(time.DateOnly, *date); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(*pass) == "" {
+		return nil, errors.New("seed: -password is required")
+	}
+
+	return func(l *logrus.Logger, db *pg.Postgres, h password.Hasher) error {
+		start := time.Now()
+		population := seed.Generate(settings)
+
+		// every user shares the hash, hashing thousands of passwords would
+		// take longer than inserting them
+		hashed, err := h.Hash(*pass)
+		if err != nil {
+			return err
+		}
+
+		if err := seed.Write(context.Background(), db, population, hashed); err != nil {
+			return err
+		}
+
+		l.WithFields(logrus.Fields{
+			"users":      len(population.Users),
+			"swipes":     len(population.Swipes),
+			"matches":    len(population.Matches),
+			"latency_ms": time.Since(start).Milliseconds(),
+		}).Info("database seeded")
+		return nil
+	}, nil
+}
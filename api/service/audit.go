@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/muzz/api/pkg/client"
+	"github.com/muzz/api/pkg/logger"
+	"github.com/muzz/api/pkg/slice"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository/model"
+	"github.com/muzz/api/service/entity"
+	"github.com/muzz/api/service/transformer"
+)
+
+// auditDetails holds the event specific fields of an audit event.
+type auditDetails map[string]any
+
+// audit appends an event to the audit log. actorID is the user performing
+// the action and userID the account it is about, 0 when unknown. Recording
+// the event must not get in the way of the action itself, so failures are
+// logged rather than returned.
+func (s UserService) audit(ctx context.Context, event string, actorID, userID int, details auditDetails) {
+	info := client.FromContext(ctx)
+
+	in := model.AuditEventInput{
+		Type:      event,
+		ActorID:   optionalID(actorID),
+		UserID:    optionalID(userID),
+		IP:        optionalString(info.IP),
+		UserAgent: optionalString(info.UserAgent),
+	}
+
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			logger.FromContext(ctx, s.l).WithError(err).WithField("event", event).Error("failed to encode audit event details")
+		}
+		in.Details = b
+	}
+
+	if err := s.auditRepo.CreateAuditEvent(ctx, in); err != nil {
+		logger.FromContext(ctx, s.l).WithError(err).WithField("event", event).Error("failed to record audit event")
+	}
+}
+
+func (s UserService) GetAuditEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetAuditEvents")
+	defer span.End()
+
+	events, err := s.auditRepo.GetAuditEvents(ctx, transformer.FromAuditFilterEntityToModel(filter))
+	if err != nil {
+		return []entity.AuditEvent{}, err
+	}
+	return slice.Map(events, transformer.FromAuditEventModelToEntity), nil
+}
+
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -2,12 +2,20 @@ package service
 
 import (
 	"context"
+	"errors"
 
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/tracing"
 	"github.com/muzz/api/repository"
 )
 
+var (
+	ErrSessionRevoked = errs.New(errs.Unauthorized, "session_revoked", "session revoked")
+)
+
 type AuthConnector interface {
 	GetTokenClaims(ctx context.Context, token string, out any) error
+	TouchSession(ctx context.Context, sessionID string, expires int64) error
 }
 
 type AuthService struct {
@@ -21,5 +29,23 @@ func NewAuthService(authRepo repository.AuthConnector) AuthService {
 }
 
 func (s AuthService) GetTokenClaims(ctx context.Context, token string, out any) error {
+	ctx, span := tracing.Start(ctx, "AuthService.GetTokenClaims")
+	defer span.End()
+
 	return s.authRepo.GetTokenClaims(ctx, token, out)
 }
+
+// TouchSession marks the session as active, failing with ErrSessionRevoked
+// once it has been deleted.
+func (s AuthService) TouchSession(ctx context.Context, sessionID string, expires int64) error {
+	ctx, span := tracing.Start(ctx, "AuthService.TouchSession")
+	defer span.End()
+
+	if err := s.authRepo.TouchSession(ctx, sessionID, expires); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	return nil
+}
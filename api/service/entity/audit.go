@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditUserCreated            = "user.created"
+	AuditLoginSucceeded         = "login.succeeded"
+	AuditLoginFailed            = "login.failed"
+	AuditLoginThrottled         = "login.throttled"
+	AuditAccountLocked          = "account.locked"
+	AuditAccountUnlocked        = "account.unlocked"
+	AuditEmailVerified          = "email.verified"
+	AuditPhoneVerified          = "phone.verified"
+	AuditPasswordResetRequested = "password.reset_requested"
+	AuditPasswordReset          = "password.reset"
+	AuditPasswordChanged        = "password.changed"
+	AuditTokensRevoked          = "tokens.revoked"
+	AuditSessionDeleted         = "session.deleted"
+	AuditTwoFactorEnabled       = "two_factor.enabled"
+	AuditTwoFactorDisabled      = "two_factor.disabled"
+	AuditPasskeyRegistered      = "passkey.registered"
+	AuditRoleUpdated            = "role.updated"
+)
+
+type AuditEvent struct {
+	ID        int64
+	Type      string
+	ActorID   *int
+	UserID    *int
+	IP        string
+	UserAgent string
+	Details   json.RawMessage
+	CreatedAt time.Time
+}
+
+type AuditFilter struct {
+	UserID *int
+	From   *time.Time
+	To     *time.Time
+	Limit  uint64
+}
@@ -2,30 +2,62 @@ package entity
 
 import "time"
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type UserInput struct {
 	Email        string
+	Phone        string
 	Password     string
 	Name         string
 	Gender       string
 	DOB          string
 	LocationLat  *float64
 	LocationLong *float64
+	Role         string
 }
 
 type User struct {
-	ID           int64
-	Email        string
-	Password     string
-	Name         string
-	Gender       string
-	DOB          time.Time
-	LocationLat  *float64
-	LocationLong *float64
+	ID            int64
+	Email         string
+	Phone         string
+	Password      string
+	Name          string
+	Gender        string
+	DOB           time.Time
+	LocationLat   *float64
+	LocationLong  *float64
+	Role          string
+	EmailVerified bool
+	PhoneVerified bool
 }
 
 type Token struct {
-	Token   string
-	Expires int64
+	Token             string
+	Expires           int64
+	TwoFactorRequired bool
+}
+
+type Session struct {
+	ID         string
+	DeviceName string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+}
+
+type PasskeyCeremony struct {
+	SessionID string
+	Options   []byte
+}
+
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
 }
 
 type Match struct {
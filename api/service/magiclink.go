@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/muzz/api/pkg/client"
+	"github.com/muzz/api/pkg/logger"
+	"github.com/muzz/api/pkg/mail"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/service/entity"
+)
+
+// RequestMagicLink emails a passwordless login link to the owner of email.
+// The link only works on the device that asked for it. Unknown emails and
+// requests over the attempt limit are silently ignored so the endpoint
+// neither discloses accounts nor floods inboxes.
+func (s UserService) RequestMagicLink(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "UserService.RequestMagicLink")
+	defer span.End()
+
+	key := "magic_link:" + strings.ToLower(strings.TrimSpace(email))
+
+	attempts, err := s.authRepo.CountAttempt(ctx, key, s.settings.LoginAttemptWindow)
+	if err != nil {
+		return err
+	}
+
+	if attempts > s.settings.LoginMaxAttempts {
+		return nil
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	fingerprint := client.FromContext(ctx).Fingerprint()
+
+	token, err := s.authRepo.GenerateMagicLinkToken(ctx, int(user.ID), *user.Email, fingerprint, s.settings.MagicLinkTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.settings.MagicLinkURL, url.QueryEscape(token))
+
+	// a failure is only logged, answering differently than for unknown
+	// emails would disclose the account
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      []string{*user.Email},
+		Subject: "Your Muzz login link",
+		Body: fmt.Sprintf(
+			"Follow the link below to log in to Muzz:\n\n%s\n\nThe link works once, on the device you requested it from, and expires in %s. If you did not ask for it you can ignore this email.\n",
+			link, s.settings.MagicLinkTTL,
+		),
+	}); err != nil {
+		logger.FromContext(ctx, s.l).WithError(err).Error("failed to send login link email")
+	}
+
+	return nil
+}
+
+// LoginMagicLink exchanges a magic link token for a session token. Opening
+// the link proves ownership of the email, so the email is verified as well.
+func (s UserService) LoginMagicLink(ctx context.Context, token string) (entity.Token, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginMagicLink")
+	defer span.End()
+
+	claims, err := s.authRepo.ConsumeMagicLinkToken(ctx, token, client.FromContext(ctx).Fingerprint())
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	// fails when the email changed after the link was sent
+	user, err := s.userRepo.VerifyEmail(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return entity.Token{}, repository.ErrInvalidMagicLinkToken
+		}
+		return entity.Token{}, err
+	}
+
+	return s.completeLogin(ctx, user, "magic_link")
+}
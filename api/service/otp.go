@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/muzz/api/pkg/sms"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/service/entity"
+)
+
+// RequestOTP texts a one-time login code to phone. Sends are limited per
+// number to keep the sms bill in check, and unknown numbers are silently
+// ignored so the endpoint does not disclose accounts.
+func (s UserService) RequestOTP(ctx context.Context, phone string) error {
+	ctx, span := tracing.Start(ctx, "UserService.RequestOTP")
+	defer span.End()
+
+	sendKey := "otp_send:" + phone
+
+	blocked, err := s.authRepo.BlockedFor(ctx, sendKey)
+	if err != nil {
+		return err
+	}
+
+	if blocked > 0 {
+		return ErrLoginThrottled.WithRetryAfter(blocked)
+	}
+
+	sends, err := s.authRepo.CountAttempt(ctx, sendKey, s.settings.LoginAttemptWindow)
+	if err != nil {
+		return err
+	}
+
+	if sends > s.settings.OTPMaxSends {
+		if err := s.authRepo.Block(ctx, sendKey, s.settings.LoginAttemptWindow); err != nil {
+			return err
+		}
+		return ErrLoginThrottled.WithRetryAfter(s.settings.LoginAttemptWindow)
+	}
+
+	user, err := s.userRepo.GetUserByPhone(ctx, phone)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	key := otpLoginKey(phone)
+
+	code, err := s.authRepo.IssueOTP(ctx, key, s.settings.OTPTTL)
+	if err != nil {
+		return err
+	}
+
+	// a fresh code comes with a fresh set of attempts
+	if err := s.authRepo.ResetAttempts(ctx, key); err != nil {
+		return err
+	}
+
+	return s.sms.Send(ctx, sms.Message{
+		To:   *user.Phone,
+		Body: fmt.Sprintf("Your Muzz login code is %s. It expires in %s.", code, s.settings.OTPTTL),
+	})
+}
+
+// LoginOTP exchanges a code texted by RequestOTP for a session token. The
+// code is discarded once more than OTPMaxAttempts guesses were made.
+func (s UserService) LoginOTP(ctx context.Context, phone, code string) (entity.Token, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginOTP")
+	defer span.End()
+
+	key := otpLoginKey(phone)
+
+	// the attempt is counted before the code is checked, so that concurrent
+	// guesses cannot get past the limit
+	attempts, err := s.authRepo.CountAttempt(ctx, key, s.settings.OTPTTL)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	if attempts > s.settings.OTPMaxAttempts {
+		if err := s.authRepo.DiscardOTP(ctx, key); err != nil {
+			return entity.Token{}, err
+		}
+
+		s.audit(ctx, entity.AuditLoginFailed, 0, 0, auditDetails{"method": "otp", "reason": "too_many_attempts", "phone": phone})
+		return entity.Token{}, repository.ErrInvalidOTP
+	}
+
+	if err := s.authRepo.ConsumeOTP(ctx, key, code); err != nil {
+		if !errors.Is(err, repository.ErrInvalidOTP) {
+			return entity.Token{}, err
+		}
+
+		s.audit(ctx, entity.AuditLoginFailed, 0, 0, auditDetails{"method": "otp", "reason": "invalid_code", "phone": phone})
+		return entity.Token{}, repository.ErrInvalidOTP
+	}
+
+	if err := s.authRepo.ResetAttempts(ctx, key); err != nil {
+		return entity.Token{}, err
+	}
+
+	user, err := s.userRepo.GetUserByPhone(ctx, phone)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return entity.Token{}, repository.ErrInvalidOTP
+		}
+		return entity.Token{}, err
+	}
+
+	// receiving the code proves ownership of the number
+	if !user.PhoneVerified {
+		if user, err = s.userRepo.VerifyPhone(ctx, int(user.ID), phone); err != nil {
+			return entity.Token{}, err
+		}
+
+		s.audit(ctx, entity.AuditPhoneVerified, int(user.ID), int(user.ID), nil)
+	}
+
+	return s.completeLogin(ctx, user, "otp")
+}
+
+func otpLoginKey(phone string) string {
+	return "otp_login:" + phone
+}
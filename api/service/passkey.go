@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/repository/model"
+	"github.com/muzz/api/service/entity"
+)
+
+const (
+	passkeyRegistrationPurpose = "passkey_registration"
+	passkeyLoginPurpose        = "passkey_login"
+)
+
+var (
+	ErrInvalidPasskey = errs.New(errs.Unauthorized, "invalid_passkey", "invalid passkey")
+	ErrPasskeyCloned  = errs.New(errs.Unauthorized, "passkey_cloned", "passkey signature counter went backwards, the authenticator may be cloned")
+)
+
+type WebAuthnSettings struct {
+	RPID          string   `env:"WEBAUTHN_RP_ID" envDefault:"localhost"`
+	RPDisplayName string   `env:"WEBAUTHN_RP_DISPLAY_NAME" envDefault:"Muzz"`
+	RPOrigins     []string `env:"WEBAUTHN_RP_ORIGINS" envSeparator:"," envDefault:"http://localhost:3000"`
+	// Timeout bounds how long a ceremony may take, its session data being
+	// dropped once it elapses.
+	Timeout time.Duration `env:"WEBAUTHN_TIMEOUT" envDefault:"5m"`
+}
+
+func NewWebAuthn(settings WebAuthnSettings) (*webauthn.WebAuthn, error) {
+	if settings.Timeout <= 0 {
+		return nil, errors.New("WEBAUTHN_TIMEOUT must be positive")
+	}
+
+	// the session data only gets an expiry when the timeouts are enforced
+	timeout := webauthn.TimeoutConfig{
+		Enforce:    true,
+		Timeout:    settings.Timeout,
+		TimeoutUVD: settings.Timeout,
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          settings.RPID,
+		RPDisplayName: settings.RPDisplayName,
+		RPOrigins:     settings.RPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+}
+
+// passkeyUser adapts a user and its passkeys to the webauthn library.
+type passkeyUser struct {
+	user        model.User
+	credentials []webauthn.Credential
+}
+
+func (u passkeyUser) WebAuthnID() []byte {
+	return []byte(strconv.FormatInt(u.user.ID, 10))
+}
+
+func (u passkeyUser) WebAuthnName() string {
+	return accountName(u.user)
+}
+
+func (u passkeyUser) WebAuthnDisplayName() string {
+	return u.user.Name
+}
+
+func (u passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// BeginPasskeyRegistration starts the registration ceremony of a new passkey
+// for the authenticated user. The returned options are handed as is to
+// navigator.credentials.create() by the client.
+func (s UserService) BeginPasskeyRegistration(ctx context.Context, userID int) (entity.PasskeyCeremony, error) {
+	ctx, span := tracing.Start(ctx, "UserService.BeginPasskeyRegistration")
+	defer span.End()
+
+	user, err := s.getPasskeyUser(ctx, userID)
+	if err != nil {
+		return entity.PasskeyCeremony{}, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, len(user.credentials))
+	for i, credential := range user.credentials {
+		exclusions[i] = credential.Descriptor()
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(exclusions),
+	)
+	if err != nil {
+		return entity.PasskeyCeremony{}, err
+	}
+
+	return s.startCeremony(ctx, passkeyRegistrationPurpose, creation, session)
+}
+
+func (s UserService) FinishPasskeyRegistration(ctx context.Context, userID int, sessionID string, response []byte) error {
+	ctx, span := tracing.Start(ctx, "UserService.FinishPasskeyRegistration")
+	defer span.End()
+
+	session, err := s.finishCeremony(ctx, passkeyRegistrationPurpose, sessionID)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.getPasskeyUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// the ceremony must be finished by the same user who started it
+	if !bytes.Equal(session.UserID, user.WebAuthnID()) {
+		return repository.ErrInvalidOneTimeToken
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return ErrInvalidPasskey.WithDetail(protocolDetails(err))
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, session, parsed)
+	if err != nil {
+		return ErrInvalidPasskey.WithDetail(protocolDetails(err))
+	}
+
+	if err := s.passkeyRepo.CreatePasskey(ctx, toPasskeyModel(userID, credential)); err != nil {
+		return err
+	}
+
+	s.audit(ctx, entity.AuditPasskeyRegistered, userID, userID, nil)
+	return nil
+}
+
+// BeginPasskeyLogin starts a discoverable login ceremony, the authenticator
+// picks the account so the client does not need to know the email.
+func (s UserService) BeginPasskeyLogin(ctx context.Context) (entity.PasskeyCeremony, error) {
+	ctx, span := tracing.Start(ctx, "UserService.BeginPasskeyLogin")
+	defer span.End()
+
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return entity.PasskeyCeremony{}, err
+	}
+
+	return s.startCeremony(ctx, passkeyLoginPurpose, assertion, session)
+}
+
+// FinishPasskeyLogin verifies the assertion, tracks the signature counter of
+// the passkey and issues a session token for its owner.
+func (s UserService) FinishPasskeyLogin(ctx context.Context, sessionID string, response []byte) (entity.Token, error) {
+	ctx, span := tracing.Start(ctx, "UserService.FinishPasskeyLogin")
+	defer span.End()
+
+	session, err := s.finishCeremony(ctx, passkeyLoginPurpose, sessionID)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return entity.Token{}, ErrInvalidPasskey.WithDetail(protocolDetails(err))
+	}
+
+	var owner passkeyUser
+	_, credential, err := s.webAuthn.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := strconv.Atoi(string(userHandle))
+		if err != nil {
+			return nil, err
+		}
+
+		owner, err = s.getPasskeyUser(ctx, userID)
+		return owner, err
+	}, session, parsed)
+	if err != nil {
+		return entity.Token{}, ErrInvalidPasskey.WithDetail(protocolDetails(err))
+	}
+
+	authenticator := credential.Authenticator
+	if err := s.passkeyRepo.UpdatePasskeyUsage(ctx, credential.ID, int64(authenticator.SignCount), authenticator.CloneWarning); err != nil {
+		return entity.Token{}, err
+	}
+
+	if authenticator.CloneWarning {
+		return entity.Token{}, ErrPasskeyCloned
+	}
+
+	return s.issueToken(ctx, owner.user, "passkey")
+}
+
+func (s UserService) getPasskeyUser(ctx context.Context, userID int) (passkeyUser, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return passkeyUser{}, err
+	}
+
+	passkeys, err := s.passkeyRepo.GetPasskeys(ctx, userID)
+	if err != nil {
+		return passkeyUser{}, err
+	}
+
+	credentials := make([]webauthn.Credential, len(passkeys))
+	for i, passkey := range passkeys {
+		credentials[i] = fromPasskeyModel(passkey)
+	}
+
+	return passkeyUser{user: user, credentials: credentials}, nil
+}
+
+// startCeremony keeps the ceremony session data server side, the client only
+// gets an opaque id to send back when finishing the ceremony.
+func (s UserService) startCeremony(ctx context.Context, purpose string, options any, session *webauthn.SessionData) (entity.PasskeyCeremony, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return entity.PasskeyCeremony{}, err
+	}
+
+	opts, err := json.Marshal(options)
+	if err != nil {
+		return entity.PasskeyCeremony{}, err
+	}
+
+	// the ceremony session expires along with the webauthn timeout, which
+	// both ceremonies share
+	ttl := s.webAuthn.Config.Timeouts.Login.Timeout
+	if !session.Expires.IsZero() {
+		ttl = time.Until(session.Expires)
+	}
+
+	sessionID, err := s.authRepo.IssueOneTimeToken(ctx, purpose, string(data), ttl)
+	if err != nil {
+		return entity.PasskeyCeremony{}, err
+	}
+
+	return entity.PasskeyCeremony{
+		SessionID: sessionID,
+		Options:   opts,
+	}, nil
+}
+
+func (s UserService) finishCeremony(ctx context.Context, purpose, sessionID string) (webauthn.SessionData, error) {
+	var session webauthn.SessionData
+
+	data, err := s.authRepo.ConsumeOneTimeToken(ctx, purpose, sessionID)
+	if err != nil {
+		return session, err
+	}
+
+	err = json.Unmarshal([]byte(data), &session)
+	return session, err
+}
+
+func toPasskeyModel(userID int, in *webauthn.Credential) model.Passkey {
+	transports := make([]string, len(in.Transport))
+	for i, transport := range in.Transport {
+		transports[i] = string(transport)
+	}
+
+	return model.Passkey{
+		ID:              in.ID,
+		UserID:          userID,
+		PublicKey:       in.PublicKey,
+		AttestationType: in.AttestationType,
+		AAGUID:          in.Authenticator.AAGUID,
+		Transports:      strings.Join(transports, ","),
+		UserVerified:    in.Flags.UserVerified,
+		BackupEligible:  in.Flags.BackupEligible,
+		BackupState:     in.Flags.BackupState,
+		SignCount:       int64(in.Authenticator.SignCount),
+	}
+}
+
+func fromPasskeyModel(in model.Passkey) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	if in.Transports != "" {
+		for _, transport := range strings.Split(in.Transports, ",") {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              in.ID,
+		PublicKey:       in.PublicKey,
+		AttestationType: in.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserVerified:   in.UserVerified,
+			BackupEligible: in.BackupEligible,
+			BackupState:    in.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       in.AAGUID,
+			SignCount:    uint32(in.SignCount),
+			CloneWarning: in.CloneWarning,
+		},
+	}
+}
+
+// protocolDetails extracts the human readable part of webauthn protocol errors.
+func protocolDetails(err error) string {
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) && protocolErr.Details != "" {
+		return protocolErr.Details
+	}
+	return err.Error()
+}
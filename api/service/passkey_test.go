@@ -0,0 +1,408 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/muzz/api/pkg/password"
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/repository/model"
+	"github.com/muzz/api/service"
+	"github.com/muzz/api/service/entity"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rpID   = "localhost"
+	origin = "http://localhost:3000"
+)
+
+// authenticator flags, see §6.1 of the webauthn specification
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	ctx := context.Background()
+	s, userID := newPasskeyService(t, 5*time.Minute)
+	a := newAuthenticator(t)
+
+	register(t, s, userID, a)
+
+	// counters going up are accepted
+	for _, count := range []uint32{1, 5} {
+		a.count = count
+
+		token, err := login(ctx, s, a)
+		if err != nil {
+			t.Fatalf("login with counter %d: %v", count, err)
+		}
+		if token.Token == "" || token.TwoFactorRequired {
+			t.Fatalf("login with counter %d: got %+v, want a session token", count, token)
+		}
+	}
+
+	// a counter going backwards means the authenticator was cloned
+	a.count = 3
+	if _, err := login(ctx, s, a); !errors.Is(err, service.ErrPasskeyCloned) {
+		t.Fatalf("login with a lower counter: got %v, want %v", err, service.ErrPasskeyCloned)
+	}
+}
+
+func TestPasskeyCeremonies(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		finish  func(t *testing.T, s service.UserService, userID int, a *authenticator) error
+		want    error
+	}{
+		{
+			name:    "registration finished twice",
+			timeout: time.Minute,
+			finish: func(t *testing.T, s service.UserService, userID int, a *authenticator) error {
+				ceremony, err := s.BeginPasskeyRegistration(ctx, userID)
+				if err != nil {
+					return err
+				}
+
+				response := a.create(ceremony.Options)
+				if err := s.FinishPasskeyRegistration(ctx, userID, ceremony.SessionID, response); err != nil {
+					return err
+				}
+				return s.FinishPasskeyRegistration(ctx, userID, ceremony.SessionID, response)
+			},
+			want: repository.ErrInvalidOneTimeToken,
+		},
+		{
+			name:    "registration finished by another user",
+			timeout: time.Minute,
+			finish: func(t *testing.T, s service.UserService, userID int, a *authenticator) error {
+				ceremony, err := s.BeginPasskeyRegistration(ctx, userID)
+				if err != nil {
+					return err
+				}
+				return s.FinishPasskeyRegistration(ctx, userID+1, ceremony.SessionID, a.create(ceremony.Options))
+			},
+			want: repository.ErrInvalidOneTimeToken,
+		},
+		{
+			name:    "registration timed out",
+			timeout: time.Millisecond,
+			finish: func(t *testing.T, s service.UserService, userID int, a *authenticator) error {
+				ceremony, err := s.BeginPasskeyRegistration(ctx, userID)
+				if err != nil {
+					return err
+				}
+
+				time.Sleep(10 * time.Millisecond)
+				return s.FinishPasskeyRegistration(ctx, userID, ceremony.SessionID, a.create(ceremony.Options))
+			},
+			want: repository.ErrInvalidOneTimeToken,
+		},
+		{
+			name:    "login with an unknown passkey",
+			timeout: time.Minute,
+			finish: func(t *testing.T, s service.UserService, userID int, a *authenticator) error {
+				a.userHandle = []byte(strconv.Itoa(userID))
+
+				_, err := login(ctx, s, a)
+				return err
+			},
+			want: service.ErrInvalidPasskey,
+		},
+		{
+			name:    "login finished twice",
+			timeout: time.Minute,
+			finish: func(t *testing.T, s service.UserService, userID int, a *authenticator) error {
+				register(t, s, userID, a)
+
+				ceremony, err := s.BeginPasskeyLogin(ctx)
+				if err != nil {
+					return err
+				}
+
+				a.count++
+				response := a.get(ceremony.Options)
+				if _, err := s.FinishPasskeyLogin(ctx, ceremony.SessionID, response); err != nil {
+					return err
+				}
+				_, err = s.FinishPasskeyLogin(ctx, ceremony.SessionID, response)
+				return err
+			},
+			want: repository.ErrInvalidOneTimeToken,
+		},
+		{
+			name:    "login timed out",
+			timeout: time.Millisecond,
+			finish: func(t *testing.T, s service.UserService, userID int, a *authenticator) error {
+				ceremony, err := s.BeginPasskeyLogin(ctx)
+				if err != nil {
+					return err
+				}
+
+				time.Sleep(10 * time.Millisecond)
+				_, err = s.FinishPasskeyLogin(ctx, ceremony.SessionID, a.get(ceremony.Options))
+				return err
+			},
+			want: repository.ErrInvalidOneTimeToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, userID := newPasskeyService(t, tt.timeout)
+			a := newAuthenticator(t)
+
+			if err := tt.finish(t, s, userID, a); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// newPasskeyService returns a user service backed by the memory repositories
+// and the id of a user to register passkeys for, userID+1 being another
+// user.
+func newPasskeyService(t *testing.T, timeout time.Duration) (service.UserService, int) {
+	t.Helper()
+
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+
+	w, err := service.NewWebAuthn(service.WebAuthnSettings{
+		RPID:          rpID,
+		RPDisplayName: "Muzz",
+		RPOrigins:     []string{origin},
+		Timeout:       timeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepo := repository.NewMemoryUserRepo(l)
+	s := service.NewUserService(l,
+		userRepo,
+		repository.NewMemoryAuthRepo(l, nil, "secret"),
+		repository.NewMemoryTwoFactorRepo(l),
+		repository.NewMemoryPasskeyRepo(l),
+		repository.NewMemoryAuditRepo(l),
+		w, nil, nil, password.Policy{}, nil, service.UserSettings{},
+	)
+
+	// a second user, for ceremonies finished by someone else
+	var ids []int
+	for _, email := range []string{"p@p.com", "q@q.com"} {
+		user, err := userRepo.CreateUser(context.Background(), model.UserInput{
+			Email:    &email,
+			Password: "hashed",
+			Name:     "p",
+			Gender:   "F",
+			DOB:      "2000-01-01",
+			Role:     entity.RoleUser,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, int(user.ID))
+	}
+
+	return s, ids[0]
+}
+
+func register(t *testing.T, s service.UserService, userID int, a *authenticator) {
+	t.Helper()
+	ctx := context.Background()
+
+	ceremony, err := s.BeginPasskeyRegistration(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.FinishPasskeyRegistration(ctx, userID, ceremony.SessionID, a.create(ceremony.Options)); err != nil {
+		t.Fatalf("registration: %v", err)
+	}
+}
+
+func login(ctx context.Context, s service.UserService, a *authenticator) (entity.Token, error) {
+	ceremony, err := s.BeginPasskeyLogin(ctx)
+	if err != nil {
+		return entity.Token{}, err
+	}
+	return s.FinishPasskeyLogin(ctx, ceremony.SessionID, a.get(ceremony.Options))
+}
+
+// authenticator is a software authenticator holding a single ES256
+// credential, answering the ceremonies the way a browser would hand the
+// authenticator responses over to the client.
+type authenticator struct {
+	t          *testing.T
+	key        *ecdsa.PrivateKey
+	id         []byte
+	userHandle []byte
+	count      uint32
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatal(err)
+	}
+
+	return &authenticator{t: t, key: key, id: id}
+}
+
+// create answers navigator.credentials.create() with a none attestation.
+func (a *authenticator) create(options []byte) []byte {
+	a.t.Helper()
+
+	var creation struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+			User      struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"publicKey"`
+	}
+	a.unmarshal(options, &creation)
+
+	handle, err := base64.RawURLEncoding.DecodeString(creation.PublicKey.User.ID)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	a.userHandle = handle
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	// attested credential data: aaguid, credential id length, id and key
+	attested := make([]byte, 16, 18)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.id)))
+	attested = append(attested, a.id...)
+	attested = append(attested, publicKey...)
+
+	authData := append(a.authData(flagUserPresent|flagUserVerified|flagAttested), attested...)
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return a.marshal(map[string]any{
+		"id":    encode(a.id),
+		"rawId": encode(a.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(a.clientData("webauthn.create", creation.PublicKey.Challenge)),
+			"attestationObject": encode(attestation),
+		},
+	})
+}
+
+// get answers navigator.credentials.get() with an assertion signed by the
+// credential, carrying the current signature counter.
+func (a *authenticator) get(options []byte) []byte {
+	a.t.Helper()
+
+	var assertion struct {
+		PublicKey struct {
+			Challenge string `json:"challenge"`
+		} `json:"publicKey"`
+	}
+	a.unmarshal(options, &assertion)
+
+	clientData := a.clientData("webauthn.get", assertion.PublicKey.Challenge)
+	authData := a.authData(flagUserPresent | flagUserVerified)
+
+	hash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, hash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return a.marshal(map[string]any{
+		"id":    encode(a.id),
+		"rawId": encode(a.id),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientData),
+			"authenticatorData": encode(authData),
+			"signature":         encode(signature),
+			"userHandle":        encode(a.userHandle),
+		},
+	})
+}
+
+// authData returns the rp id hash, flags and signature counter common to
+// both ceremonies.
+func (a *authenticator) authData(flags byte) []byte {
+	hash := sha256.Sum256([]byte(rpID))
+
+	out := append(hash[:], flags)
+	return binary.BigEndian.AppendUint32(out, a.count)
+}
+
+func (a *authenticator) clientData(ceremony, challenge string) []byte {
+	return a.marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    origin,
+	})
+}
+
+func (a *authenticator) marshal(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return b
+}
+
+func (a *authenticator) unmarshal(b []byte, v any) {
+	if err := json.Unmarshal(b, v); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
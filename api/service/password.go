@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/logger"
+	"github.com/muzz/api/pkg/mail"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/service/entity"
+)
+
+const passwordResetPurpose = "password_reset"
+
+var (
+	// forbidden rather than unauthorized, the session itself is valid and
+	// clients must not take it for a logout
+	ErrInvalidPassword = errs.New(errs.Forbidden, "invalid_password", "invalid password")
+)
+
+// ForgotPassword emails a password reset link to the owner of email. Unknown
+// emails are silently ignored so the endpoint does not disclose accounts.
+func (s UserService) ForgotPassword(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "UserService.ForgotPassword")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.authRepo.IssueOneTimeToken(ctx, passwordResetPurpose, strconv.FormatInt(user.ID, 10), s.settings.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, entity.AuditPasswordResetRequested, 0, int(user.ID), nil)
+
+	link := fmt.Sprintf("%s?token=%s", s.settings.PasswordResetURL, url.QueryEscape(token))
+
+	// a failure is only logged, answering differently than for unknown
+	// emails would disclose the account
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      []string{*user.Email},
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"We received a request to reset your Muzz password.\n\nFollow the link below to choose a new one:\n\n%s\n\nThe link expires in %s. If you did not ask for a reset you can ignore this email.\n",
+			link, s.settings.PasswordResetTTL,
+		),
+	}); err != nil {
+		logger.FromContext(ctx, s.l).WithError(err).Error("failed to send password reset email")
+	}
+
+	return nil
+}
+
+func (s UserService) ResetPassword(ctx context.Context, token, password string) error {
+	ctx, span := tracing.Start(ctx, "UserService.ResetPassword")
+	defer span.End()
+
+	// checked first so that a rejected password does not burn the token
+	if err := s.policy.Check(password); err != nil {
+		return err
+	}
+
+	value, err := s.authRepo.ConsumeOneTimeToken(ctx, passwordResetPurpose, token)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, userID, password); err != nil {
+		return err
+	}
+
+	s.audit(ctx, entity.AuditPasswordReset, userID, userID, nil)
+	return nil
+}
+
+func (s UserService) ChangePassword(ctx context.Context, userID int, oldPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authRepo.ValidateHash(user.Password, oldPassword); err != nil {
+		return ErrInvalidPassword
+	}
+
+	if err := s.policy.Check(newPassword); err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, userID, newPassword); err != nil {
+		return err
+	}
+
+	s.audit(ctx, entity.AuditPasswordChanged, userID, userID, nil)
+	return nil
+}
+
+// setPassword stores the new password and logs the user out everywhere.
+func (s UserService) setPassword(ctx context.Context, userID int, password string) error {
+	hashed, err := s.authRepo.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashed); err != nil {
+		return err
+	}
+
+	if err := s.authRepo.RevokeTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, entity.AuditTokensRevoked, userID, userID, nil)
+	return nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/muzz/api/pkg/slice"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/service/entity"
+	"github.com/muzz/api/service/transformer"
+)
+
+func (s UserService) GetSessions(ctx context.Context, userID int) ([]entity.Session, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetSessions")
+	defer span.End()
+
+	sessions, err := s.authRepo.GetSessions(ctx, userID)
+	if err != nil {
+		return []entity.Session{}, err
+	}
+	return slice.Map(sessions, transformer.FromSessionModelToEntity), nil
+}
+
+func (s UserService) DeleteSession(ctx context.Context, userID int, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteSession")
+	defer span.End()
+
+	if err := s.authRepo.DeleteSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, entity.AuditSessionDeleted, userID, userID, auditDetails{"session_id": sessionID})
+	return nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muzz/api/pkg/client"
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/logger"
+	"github.com/muzz/api/pkg/mail"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/service/entity"
+)
+
+const loginUnlockPurpose = "login_unlock"
+
+var (
+	ErrInvalidCredentials = errs.New(errs.Unauthorized, "invalid_credentials", "invalid credentials")
+	ErrLoginThrottled     = errs.New(errs.RateLimited, "too_many_attempts", "too many attempts, try again later")
+)
+
+var dummy struct {
+	once sync.Once
+	hash string
+}
+
+type loginKeys struct {
+	email   string
+	account string
+	ip      string
+}
+
+func newLoginKeys(ctx context.Context, email string) loginKeys {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	keys := loginKeys{
+		email:   email,
+		account: "login:account:" + email,
+	}
+
+	// cli commands carry no client ip, those are throttled per account only
+	if ip := client.FromContext(ctx).IP; ip != "" {
+		keys.ip = "login:ip:" + ip
+	}
+	return keys
+}
+
+func (k loginKeys) all() []string {
+	if k.ip == "" {
+		return []string{k.account}
+	}
+	return []string{k.account, k.ip}
+}
+
+func (s UserService) checkLoginThrottle(ctx context.Context, keys loginKeys) error {
+	var wait time.Duration
+	for _, key := range keys.all() {
+		blocked, err := s.authRepo.BlockedFor(ctx, key)
+		if err != nil {
+			return err
+		}
+		wait = max(wait, blocked)
+	}
+
+	if wait > 0 {
+		return ErrLoginThrottled.WithRetryAfter(wait)
+	}
+	return nil
+}
+
+// loginFailed records a failed attempt, backing off exponentially on the
+// account until the threshold is reached and the account gets locked. The
+// owner, when known, is then emailed an unlock link.
+func (s UserService) loginFailed(ctx context.Context, keys loginKeys, userID int, owner string) error {
+	count, err := s.authRepo.CountAttempt(ctx, keys.account, s.settings.LoginAttemptWindow)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case count >= s.settings.LoginMaxAttempts:
+		if err := s.authRepo.Block(ctx, keys.account, s.settings.LoginLockoutDuration); err != nil {
+			return err
+		}
+
+		if count == s.settings.LoginMaxAttempts && owner != "" {
+			s.audit(ctx, entity.AuditAccountLocked, 0, userID, auditDetails{"duration": s.settings.LoginLockoutDuration.String()})
+
+			if err := s.sendUnlock(ctx, keys.email, owner); err != nil {
+				logger.FromContext(ctx, s.l).WithError(err).Error("failed to send unlock email")
+			}
+		}
+	case count > 1:
+		if err := s.authRepo.Block(ctx, keys.account, s.loginBackoff(count)); err != nil {
+			return err
+		}
+	}
+
+	if keys.ip != "" {
+		count, err := s.authRepo.CountAttempt(ctx, keys.ip, s.settings.LoginAttemptWindow)
+		if err != nil {
+			return err
+		}
+
+		if count >= s.settings.LoginIPMaxAttempts {
+			if err := s.authRepo.Block(ctx, keys.ip, s.settings.LoginLockoutDuration); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ErrInvalidCredentials
+}
+
+// loginBackoff doubles the delay for every failed attempt after the first
+// one, never exceeding the lockout duration.
+func (s UserService) loginBackoff(count int64) time.Duration {
+	backoff := s.settings.LoginBackoffBase
+	for i := int64(2); i < count && backoff < s.settings.LoginLockoutDuration; i++ {
+		backoff *= 2
+	}
+	return min(backoff, s.settings.LoginLockoutDuration)
+}
+
+func (s UserService) UnlockLogin(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "UserService.UnlockLogin")
+	defer span.End()
+
+	email, err := s.authRepo.ConsumeOneTimeToken(ctx, loginUnlockPurpose, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authRepo.ResetAttempts(ctx, newLoginKeys(ctx, email).account); err != nil {
+		return err
+	}
+
+	s.audit(ctx, entity.AuditAccountUnlocked, 0, 0, auditDetails{"email": email})
+	return nil
+}
+
+func (s UserService) sendUnlock(ctx context.Context, email, to string) error {
+	token, err := s.authRepo.IssueOneTimeToken(ctx, loginUnlockPurpose, email, s.settings.LoginUnlockTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.settings.LoginUnlockURL, url.QueryEscape(token))
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      []string{to},
+		Subject: "Your account has been locked",
+		Body: fmt.Sprintf(
+			"We locked your Muzz account after several failed login attempts.\n\nIf it was you, follow the link below to unlock it right away:\n\n%s\n\nOtherwise the lock is lifted automatically in %s. Consider changing your password if you did not try to log in.\n",
+			link, s.settings.LoginLockoutDuration,
+		),
+	})
+}
+
+// dummyHash returns a valid hash compared against when the email is unknown.
+func (s UserService) dummyHash() string {
+	dummy.once.Do(func() {
+		dummy.hash, _ = s.authRepo.HashPassword("muzz-dummy-password")
+	})
+	return dummy.hash
+}
@@ -0,0 +1,30 @@
+package transformer
+
+import (
+	"encoding/json"
+
+	"github.com/muzz/api/repository/model"
+	"github.com/muzz/api/service/entity"
+)
+
+func FromAuditEventModelToEntity(in model.AuditEvent) entity.AuditEvent {
+	return entity.AuditEvent{
+		ID:        in.ID,
+		Type:      in.Type,
+		ActorID:   in.ActorID,
+		UserID:    in.UserID,
+		IP:        value(in.IP),
+		UserAgent: value(in.UserAgent),
+		Details:   json.RawMessage(in.Details),
+		CreatedAt: in.CreatedAt,
+	}
+}
+
+func FromAuditFilterEntityToModel(in entity.AuditFilter) model.AuditFilter {
+	return model.AuditFilter{
+		UserID: in.UserID,
+		From:   in.From,
+		To:     in.To,
+		Limit:  in.Limit,
+	}
+}
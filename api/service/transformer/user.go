@@ -7,26 +7,32 @@ import (
 
 func FromUserEntityInputToModel(in entity.UserInput) model.UserInput {
 	return model.UserInput{
-		Email:        in.Email,
+		Email:        nullable(in.Email),
+		Phone:        nullable(in.Phone),
 		Password:     in.Password,
 		Name:         in.Name,
 		Gender:       in.Gender,
 		DOB:          in.DOB,
 		LocationLat:  in.LocationLat,
 		LocationLong: in.LocationLong,
+		Role:         in.Role,
 	}
 }
 
 func FromUserModelToEntity(in model.User) entity.User {
 	return entity.User{
-		ID:           in.ID,
-		Email:        in.Email,
-		Password:     in.Password,
-		Name:         in.Name,
-		Gender:       in.Gender,
-		DOB:          in.DOB,
-		LocationLat:  in.LocationLat,
-		LocationLong: in.LocationLong,
+		ID:            in.ID,
+		Email:         value(in.Email),
+		Phone:         value(in.Phone),
+		Password:      in.Password,
+		Name:          in.Name,
+		Gender:        in.Gender,
+		DOB:           in.DOB,
+		LocationLat:   in.LocationLat,
+		LocationLong:  in.LocationLong,
+		Role:          in.Role,
+		EmailVerified: in.EmailVerified,
+		PhoneVerified: in.PhoneVerified,
 	}
 }
 
@@ -37,6 +43,17 @@ func FromTokenModelToEntity(in model.Token) entity.Token {
 	}
 }
 
+func FromSessionModelToEntity(in model.Session) entity.Session {
+	return entity.Session{
+		ID:         in.ID,
+		DeviceName: in.DeviceName,
+		UserAgent:  in.UserAgent,
+		IP:         in.IP,
+		CreatedAt:  in.CreatedAt,
+		LastSeenAt: in.LastSeenAt,
+	}
+}
+
 func FromMatchModelToEntity(in model.Match) entity.Match {
 	return entity.Match{
 		ID:      in.ID,
@@ -53,3 +70,18 @@ func FromDiscoveryModelToEntity(in model.Discovery) entity.Discovery {
 		AttractivenessScore: in.AttractivenessScore,
 	}
 }
+
+// nullable maps an empty identifier to a NULL column.
+func nullable(in string) *string {
+	if in == "" {
+		return nil
+	}
+	return &in
+}
+
+func value(in *string) string {
+	if in == nil {
+		return ""
+	}
+	return *in
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muzz/api/pkg/errs"
+	"github.com/muzz/api/pkg/totp"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/repository"
+	"github.com/muzz/api/repository/model"
+	"github.com/muzz/api/service/entity"
+)
+
+const (
+	loginChallengePurpose = "login_challenge"
+
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+)
+
+var (
+	ErrTwoFactorEnabled     = errs.New(errs.Conflict, "two_factor_enabled", "two factor authentication already enabled")
+	ErrInvalidTwoFactorCode = errs.New(errs.Unauthorized, "invalid_two_factor_code", "invalid two factor code")
+)
+
+// issueChallenge returns a short-lived challenge instead of a session token,
+// to be exchanged through LoginTwoFactor together with a second factor.
+func (s UserService) issueChallenge(ctx context.Context, userID int) (entity.Token, error) {
+	challenge, err := s.authRepo.IssueOneTimeToken(ctx, loginChallengePurpose, strconv.Itoa(userID), s.settings.TwoFactorChallenge)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	return entity.Token{
+		Token:             challenge,
+		Expires:           time.Now().Add(s.settings.TwoFactorChallenge).Unix(),
+		TwoFactorRequired: true,
+	}, nil
+}
+
+// LoginTwoFactor exchanges a login challenge and a totp or recovery code for
+// a session token. Too many wrong codes burn the challenge, forcing the user
+// through the (throttled) password login again.
+func (s UserService) LoginTwoFactor(ctx context.Context, challenge, code string) (entity.Token, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LoginTwoFactor")
+	defer span.End()
+
+	value, err := s.authRepo.PeekOneTimeToken(ctx, loginChallengePurpose, challenge)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	attemptsKey := fmt.Sprintf("login:2fa:%d", userID)
+	count, err := s.authRepo.CountAttempt(ctx, attemptsKey, s.settings.TwoFactorChallenge)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	if count > s.settings.TwoFactorMaxAttempts {
+		if _, err := s.authRepo.ConsumeOneTimeToken(ctx, loginChallengePurpose, challenge); err != nil {
+			return entity.Token{}, err
+		}
+		return entity.Token{}, repository.ErrInvalidOneTimeToken
+	}
+
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTwoFactorNotFound) {
+			return entity.Token{}, repository.ErrInvalidOneTimeToken
+		}
+		return entity.Token{}, err
+	}
+
+	if err := s.verifySecondFactor(ctx, twoFactor, code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.audit(ctx, entity.AuditLoginFailed, 0, userID, auditDetails{"method": "two_factor", "reason": "invalid_code"})
+		}
+		return entity.Token{}, err
+	}
+
+	if _, err := s.authRepo.ConsumeOneTimeToken(ctx, loginChallengePurpose, challenge); err != nil {
+		return entity.Token{}, err
+	}
+
+	if err := s.authRepo.ResetAttempts(ctx, attemptsKey); err != nil {
+		return entity.Token{}, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.Token{}, err
+	}
+
+	return s.issueToken(ctx, user, "two_factor")
+}
+
+// EnrollTwoFactor generates a new pending secret, two factor authentication
+// is only enforced once ConfirmTwoFactor proves the app was set up.
+func (s UserService) EnrollTwoFactor(ctx context.Context, userID int) (entity.TwoFactorEnrollment, error) {
+	ctx, span := tracing.Start(ctx, "UserService.EnrollTwoFactor")
+	defer span.End()
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.TwoFactorEnrollment{}, err
+	}
+
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrTwoFactorNotFound) {
+		return entity.TwoFactorEnrollment{}, err
+	}
+
+	if twoFactor.Enabled {
+		return entity.TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return entity.TwoFactorEnrollment{}, err
+	}
+
+	if err := s.twoFactorRepo.SaveTwoFactorSecret(ctx, userID, secret); err != nil {
+		return entity.TwoFactorEnrollment{}, err
+	}
+
+	return entity.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.settings.TOTPIssuer, accountName(user), secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two factor authentication and returns the
+// recovery codes, which are only ever shown this once.
+func (s UserService) ConfirmTwoFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ConfirmTwoFactor")
+	defer span.End()
+
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	if err := s.verifyTOTP(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = generateRecoveryCode(); err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.twoFactorRepo.EnableTwoFactor(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, entity.AuditTwoFactorEnabled, userID, userID, nil)
+
+	return codes, nil
+}
+
+func (s UserService) DisableTwoFactor(ctx context.Context, userID int, code string) error {
+	ctx, span := tracing.Start(ctx, "UserService.DisableTwoFactor")
+	defer span.End()
+
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !twoFactor.Enabled {
+		return repository.ErrTwoFactorNotFound
+	}
+
+	if err := s.verifySecondFactor(ctx, twoFactor, code); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.DisableTwoFactor(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, entity.AuditTwoFactorDisabled, userID, userID, nil)
+	return nil
+}
+
+// verifySecondFactor accepts either a totp code or an unused recovery code.
+func (s UserService) verifySecondFactor(ctx context.Context, twoFactor model.TwoFactor, code string) error {
+	if len(code) == totp.Digits {
+		return s.verifyTOTP(ctx, twoFactor, code)
+	}
+
+	err := s.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserID, hashRecoveryCode(code))
+	if errors.Is(err, repository.ErrInvalidRecoveryCode) {
+		return ErrInvalidTwoFactorCode
+	}
+	return err
+}
+
+func (s UserService) verifyTOTP(ctx context.Context, twoFactor model.TwoFactor, code string) error {
+	step, ok := totp.Validate(twoFactor.Secret, code, time.Now(), 1)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	// a code stays valid for a few steps, make sure it is only used once
+	claimed, err := s.authRepo.ClaimOnce(ctx, fmt.Sprintf("totp:%d:%d", twoFactor.UserID, step), 3*totp.Period)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = recoveryCodeAlphabet[int(b[i])%len(recoveryCodeAlphabet)]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,11 +2,23 @@ package service
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/muzz/api/pkg/client"
+	"github.com/muzz/api/pkg/logger"
+	"github.com/muzz/api/pkg/mail"
+	"github.com/muzz/api/pkg/metrics"
+	"github.com/muzz/api/pkg/password"
 	"github.com/muzz/api/pkg/slice"
+	"github.com/muzz/api/pkg/sms"
+	"github.com/muzz/api/pkg/tracing"
 	"github.com/muzz/api/repository"
+	"github.com/muzz/api/repository/model"
 	"github.com/muzz/api/service/entity"
 	"github.com/muzz/api/service/transformer"
+	"github.com/sirupsen/logrus"
 )
 
 type UserConnector interface {
@@ -14,22 +26,110 @@ type UserConnector interface {
 	Login(ctx context.Context, email, password string) (entity.Token, error)
 	Swipe(ctx context.Context, userID, swipeUserID int, action bool) (entity.Match, error)
 	Discover(ctx context.Context, userID int, age []int, gender string) ([]entity.Discovery, error)
+	UnlockLogin(ctx context.Context, token string) error
+	RequestMagicLink(ctx context.Context, email string) error
+	LoginMagicLink(ctx context.Context, token string) (entity.Token, error)
+	RequestOTP(ctx context.Context, phone string) error
+	LoginOTP(ctx context.Context, phone, code string) (entity.Token, error)
+	GetSessions(ctx context.Context, userID int) ([]entity.Session, error)
+	DeleteSession(ctx context.Context, userID int, sessionID string) error
+	GetAuditEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error)
+	LoginTwoFactor(ctx context.Context, challenge, code string) (entity.Token, error)
+	EnrollTwoFactor(ctx context.Context, userID int) (entity.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userID int, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID int, code string) error
+	BeginPasskeyRegistration(ctx context.Context, userID int) (entity.PasskeyCeremony, error)
+	FinishPasskeyRegistration(ctx context.Context, userID int, sessionID string, response []byte) error
+	BeginPasskeyLogin(ctx context.Context) (entity.PasskeyCeremony, error)
+	FinishPasskeyLogin(ctx context.Context, sessionID string, response []byte) (entity.Token, error)
+	UpdateUserRole(ctx context.Context, actorID, userID int, role string) (entity.User, error)
+	SeedAdmin(ctx context.Context, user entity.UserInput) (entity.User, error)
+	VerifyEmail(ctx context.Context, token string) (entity.User, error)
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	ChangePassword(ctx context.Context, userID int, oldPassword, newPassword string) error
+}
+
+type UserSettings struct {
+	VerificationURL      string        `env:"VERIFICATION_URL" envDefault:"http://localhost:8080/verify-email"`
+	VerificationTTL      time.Duration `env:"VERIFICATION_TTL" envDefault:"24h"`
+	PasswordResetURL     string        `env:"PASSWORD_RESET_URL" envDefault:"http://localhost:8080/reset-password"`
+	PasswordResetTTL     time.Duration `env:"PASSWORD_RESET_TTL" envDefault:"15m"`
+	MagicLinkURL         string        `env:"MAGIC_LINK_URL" envDefault:"http://localhost:8080/magic-login"`
+	MagicLinkTTL         time.Duration `env:"MAGIC_LINK_TTL" envDefault:"10m"`
+	LoginMaxAttempts     int64         `env:"LOGIN_MAX_ATTEMPTS" envDefault:"5"`
+	LoginIPMaxAttempts   int64         `env:"LOGIN_IP_MAX_ATTEMPTS" envDefault:"50"`
+	LoginAttemptWindow   time.Duration `env:"LOGIN_ATTEMPT_WINDOW" envDefault:"15m"`
+	LoginBackoffBase     time.Duration `env:"LOGIN_BACKOFF_BASE" envDefault:"1s"`
+	LoginLockoutDuration time.Duration `env:"LOGIN_LOCKOUT_DURATION" envDefault:"15m"`
+	LoginUnlockURL       string        `env:"LOGIN_UNLOCK_URL" envDefault:"http://localhost:8080/unlock-account"`
+	LoginUnlockTTL       time.Duration `env:"LOGIN_UNLOCK_TTL" envDefault:"1h"`
+	TOTPIssuer           string        `env:"TOTP_ISSUER" envDefault:"Muzz"`
+	TwoFactorChallenge   time.Duration `env:"TWO_FACTOR_CHALLENGE_TTL" envDefault:"5m"`
+	TwoFactorMaxAttempts int64         `env:"TWO_FACTOR_MAX_ATTEMPTS" envDefault:"5"`
+	OTPTTL               time.Duration `env:"OTP_TTL" envDefault:"5m"`
+	OTPMaxAttempts       int64         `env:"OTP_MAX_ATTEMPTS" envDefault:"5"`
+	OTPMaxSends          int64         `env:"OTP_MAX_SENDS" envDefault:"3"`
+	DiscoverVerifiedOnly bool          `env:"DISCOVER_VERIFIED_ONLY" envDefault:"false"`
 }
 
 type UserService struct {
-	userRepo repository.UserConnector
-	authRepo repository.AuthConnector
+	l             *logrus.Logger
+	userRepo      repository.UserConnector
+	authRepo      repository.AuthConnector
+	twoFactorRepo repository.TwoFactorConnector
+	passkeyRepo   repository.PasskeyConnector
+	auditRepo     repository.AuditConnector
+	webAuthn      *webauthn.WebAuthn
+	mailer        mail.Sender
+	sms           sms.Sender
+	policy        password.Policy
+	metrics       *metrics.Metrics
+	settings      UserSettings
 }
 
-func NewUserService(userRepo repository.UserConnector, authRepo repository.AuthConnector) UserService {
+func NewUserService(
+	l *logrus.Logger,
+	userRepo repository.UserConnector,
+	authRepo repository.AuthConnector,
+	twoFactorRepo repository.TwoFactorConnector,
+	passkeyRepo repository.PasskeyConnector,
+	auditRepo repository.AuditConnector,
+	webAuthn *webauthn.WebAuthn,
+	mailer mail.Sender,
+	sms sms.Sender,
+	policy password.Policy,
+	metrics *metrics.Metrics,
+	settings UserSettings,
+) UserService {
 	return UserService{
-		userRepo: userRepo,
-		authRepo: authRepo,
+		l:             l,
+		userRepo:      userRepo,
+		authRepo:      authRepo,
+		twoFactorRepo: twoFactorRepo,
+		passkeyRepo:   passkeyRepo,
+		auditRepo:     auditRepo,
+		webAuthn:      webAuthn,
+		mailer:        mailer,
+		sms:           sms,
+		policy:        policy,
+		metrics:       metrics,
+		settings:      settings,
 	}
 }
 
 func (s UserService) CreateUser(ctx context.Context, user entity.UserInput) (entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.CreateUser")
+	defer span.End()
+
 	in := transformer.FromUserEntityInputToModel(user)
+	if in.Role == "" {
+		in.Role = entity.RoleUser
+	}
+
+	if err := s.policy.Check(in.Password); err != nil {
+		return entity.User{}, err
+	}
 
 	// hash password before storing
 	hashed, err := s.authRepo.HashPassword(in.Password)
@@ -44,44 +144,187 @@ func (s UserService) CreateUser(ctx context.Context, user entity.UserInput) (ent
 		return entity.User{}, err
 	}
 
+	s.audit(ctx, entity.AuditUserCreated, int(userM.ID), int(userM.ID), auditDetails{"role": userM.Role})
+	s.metrics.UserCreated()
+
+	// the account exists at this point, a failed delivery must not fail the sign-up
+	if userM.Email != nil && !userM.EmailVerified {
+		if err := s.sendVerification(ctx, int(userM.ID), *userM.Email); err != nil {
+			logger.FromContext(ctx, s.l).WithError(err).Error("failed to send verification email")
+		}
+	}
+
 	return transformer.FromUserModelToEntity(userM), nil
 }
 
+// Login authenticates the user, failing with ErrInvalidCredentials whether
+// the email is unknown or the password is wrong so accounts are not disclosed.
 func (s UserService) Login(ctx context.Context, email, password string) (entity.Token, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+
+	keys := newLoginKeys(ctx, email)
+	if err := s.checkLoginThrottle(ctx, keys); err != nil {
+		s.audit(ctx, entity.AuditLoginThrottled, 0, 0, auditDetails{"email": keys.email})
+		return entity.Token{}, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return entity.Token{}, err
+		}
+
+		// keep the response time in line with a wrong password
+		_ = s.authRepo.ValidateHash(s.dummyHash(), password)
+		s.audit(ctx, entity.AuditLoginFailed, 0, 0, auditDetails{"method": "password", "reason": "unknown_account", "email": keys.email})
+		return entity.Token{}, s.loginFailed(ctx, keys, 0, "")
+	}
+
+	if err := s.authRepo.ValidateHash(user.Password, password); err != nil {
+		s.audit(ctx, entity.AuditLoginFailed, 0, int(user.ID), auditDetails{"method": "password", "reason": "invalid_password"})
+		return entity.Token{}, s.loginFailed(ctx, keys, int(user.ID), *user.Email)
+	}
+
+	if err := s.authRepo.ResetAttempts(ctx, keys.account); err != nil {
 		return entity.Token{}, err
 	}
 
+	s.rehashPassword(ctx, user, password)
+
+	return s.completeLogin(ctx, user, "password")
+}
+
+// rehashPassword migrates the stored hash of user to the current algorithm
+// and parameters while the plain password is at hand. Failing to do so only
+// delays the migration to the next login, so errors are logged.
+func (s UserService) rehashPassword(ctx context.Context, user model.User, password string) {
+	if !s.authRepo.NeedsRehash(user.Password) {
+		return
+	}
+
 	hashed, err := s.authRepo.HashPassword(password)
 	if err != nil {
-		return entity.Token{}, err
+		logger.FromContext(ctx, s.l).WithError(err).Error("failed to rehash password")
+		return
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, int(user.ID), hashed); err != nil {
+		logger.FromContext(ctx, s.l).WithError(err).Error("failed to store rehashed password")
+	}
+}
+
+// accountName returns the identifier the user knows the account by, which is
+// the phone number for accounts created without an email.
+func accountName(user model.User) string {
+	if user.Email != nil {
+		return *user.Email
+	}
+	if user.Phone != nil {
+		return *user.Phone
 	}
+	return ""
+}
 
-	if err := s.authRepo.ValidateHash(hashed, password); err != nil {
+// completeLogin finishes a login once the first factor, named by method, has
+// been checked, asking for the second factor when the user enabled it.
+func (s UserService) completeLogin(ctx context.Context, user model.User, method string) (entity.Token, error) {
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, int(user.ID))
+	if err != nil && !errors.Is(err, repository.ErrTwoFactorNotFound) {
 		return entity.Token{}, err
 	}
 
-	token, err := s.authRepo.GenerateToken(ctx, int(user.ID))
+	if twoFactor.Enabled {
+		return s.issueChallenge(ctx, int(user.ID))
+	}
+
+	return s.issueToken(ctx, user, method)
+}
+
+// issueToken is the single place session tokens are handed out from, every
+// login flow ends up here once the user is fully authenticated.
+func (s UserService) issueToken(ctx context.Context, user model.User, method string) (entity.Token, error) {
+	info := client.FromContext(ctx)
+
+	token, err := s.authRepo.GenerateToken(ctx, int(user.ID), user.Role, model.Session{
+		DeviceName: info.DeviceName,
+		UserAgent:  info.UserAgent,
+		IP:         info.IP,
+	})
 	if err != nil {
 		return entity.Token{}, err
 	}
 
+	s.audit(ctx, entity.AuditLoginSucceeded, int(user.ID), int(user.ID), auditDetails{"method": method})
+
 	return transformer.FromTokenModelToEntity(token), nil
 }
 
 func (s UserService) Swipe(ctx context.Context, userID, swipeUserID int, action bool) (entity.Match, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Swipe")
+	defer span.End()
+
 	swipe, err := s.userRepo.Swipe(ctx, userID, swipeUserID, action)
 	if err != nil {
 		return entity.Match{}, err
 	}
+
+	s.metrics.Swiped(action)
+	if swipe.Created {
+		s.metrics.MatchCreated()
+	}
 	return transformer.FromMatchModelToEntity(swipe), nil
 }
 
 func (s UserService) Discover(ctx context.Context, userID int, age []int, gender string) ([]entity.Discovery, error) {
-	profiles, err := s.userRepo.Discover(ctx, userID, age, gender)
+	ctx, span := tracing.Start(ctx, "UserService.Discover")
+	defer span.End()
+
+	profiles, err := s.userRepo.Discover(ctx, userID, age, gender, s.settings.DiscoverVerifiedOnly)
 	if err != nil {
 		return []entity.Discovery{}, err
 	}
+
+	s.metrics.Discovered(len(profiles))
 	return slice.Map(profiles, transformer.FromDiscoveryModelToEntity), nil
 }
+
+func (s UserService) UpdateUserRole(ctx context.Context, actorID, userID int, role string) (entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUserRole")
+	defer span.End()
+
+	user, err := s.userRepo.UpdateUserRole(ctx, userID, role)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	s.audit(ctx, entity.AuditRoleUpdated, actorID, userID, auditDetails{"role": role})
+
+	// tokens carry the role, so the user logs in again to pick up the new one
+	if err := s.authRepo.RevokeTokens(ctx, userID); err != nil {
+		return entity.User{}, err
+	}
+
+	s.audit(ctx, entity.AuditTokensRevoked, actorID, userID, nil)
+
+	return transformer.FromUserModelToEntity(user), nil
+}
+
+// SeedAdmin makes sure an admin account exists for the given email, creating
+// it when missing or promoting the existing account otherwise.
+func (s UserService) SeedAdmin(ctx context.Context, user entity.UserInput) (entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SeedAdmin")
+	defer span.End()
+
+	existing, err := s.userRepo.GetUserByEmail(ctx, user.Email)
+	if err != nil {
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return entity.User{}, err
+		}
+
+		user.Role = entity.RoleAdmin
+		return s.CreateUser(ctx, user)
+	}
+
+	return s.UpdateUserRole(ctx, 0, int(existing.ID), entity.RoleAdmin)
+}
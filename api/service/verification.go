@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/muzz/api/pkg/mail"
+	"github.com/muzz/api/pkg/tracing"
+	"github.com/muzz/api/service/entity"
+	"github.com/muzz/api/service/transformer"
+)
+
+func (s UserService) VerifyEmail(ctx context.Context, token string) (entity.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.VerifyEmail")
+	defer span.End()
+
+	claims, err := s.authRepo.ConsumeVerificationToken(ctx, token)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	user, err := s.userRepo.VerifyEmail(ctx, claims.UserID, claims.Email)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	s.audit(ctx, entity.AuditEmailVerified, claims.UserID, claims.UserID, nil)
+
+	return transformer.FromUserModelToEntity(user), nil
+}
+
+func (s UserService) sendVerification(ctx context.Context, uid int, email string) error {
+	token, err := s.authRepo.GenerateVerificationToken(ctx, uid, email, s.settings.VerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.settings.VerificationURL, url.QueryEscape(token))
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      []string{email},
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Welcome to Muzz!\n\nPlease confirm your email address by following the link below:\n\n%s\n\nThe link expires in %s.\n",
+			link, s.settings.VerificationTTL,
+		),
+	})
+}
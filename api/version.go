@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// version is set at build time: go build -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// printVersion prints the version of the binary along with the commit it was
+// built from, when known.
+func printVersion() {
+	revision := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
+	}
+
+	fmt.Printf("muzz %s (commit %s)\n", version, revision)
+}
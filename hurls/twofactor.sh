@@ -0,0 +1,24 @@
+#!/bin/sh
+# Runs twofactor.hurl, which needs totp codes computed from the secret the
+# enrollment returns. Requires hurl, jq and oathtool.
+set -e
+cd "$(dirname "$0")"
+
+enrollment=$(hurl twofactor_enroll.hurl)
+secret=$(echo "$enrollment" | jq -r .secret)
+
+# codes stay valid one period either side, wait for a fresh period so that
+# the previous code is still accepted by the end of the run
+while [ $(( $(date +%s) % 30 )) -gt 20 ]; do
+	sleep 1
+done
+
+code() {
+	oathtool --totp -b -N "$1" "$secret"
+}
+
+hurl --test \
+	--variable previous="$(code 'now - 30 seconds')" \
+	--variable current="$(code now)" \
+	--variable next="$(code 'now + 30 seconds')" \
+	twofactor.hurl